@@ -0,0 +1,732 @@
+package utils
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Default archive extraction limits.
+const (
+	DefaultArchiveMaxSize  int64 = 1 << 30 // 1 GiB
+	DefaultArchiveMaxFiles       = 10000
+	DefaultArchiveMaxRatio       = 100
+)
+
+// archiveRatioMinSize is the uncompressed size below which the compression ratio is not checked,
+// so small highly compressible archives (e.g. a file of zeros) are accepted.
+const archiveRatioMinSize = 1 << 20
+
+type (
+	// ArchiveOption configures archive extraction.
+	ArchiveOption func(*archiveOptions)
+
+	archiveOptions struct {
+		maxSize  int64
+		maxFiles int
+		maxRatio int64
+	}
+
+	// archiveEntry is a single file of an archive.
+	archiveEntry struct {
+		name    string
+		mode    fs.FileMode
+		modTime time.Time
+		// link is the symlink target.
+		link string
+	}
+
+	// archiveSink stores the extracted entries.
+	archiveSink interface {
+		mkdir(name string, perm fs.FileMode) error
+		create(name string, perm fs.FileMode, modTime time.Time, r io.Reader) error
+		symlink(name, target string) error
+	}
+
+	// archiveExtractor validates the entries and enforces the limits.
+	archiveExtractor struct {
+		o    *archiveOptions
+		sink archiveSink
+		// compressed returns the number of compressed bytes processed so far.
+		compressed func() int64
+		files      int
+		written    int64
+	}
+
+	// archiveCounter counts the bytes read through it into the extractor and checks the limits.
+	archiveCounter struct {
+		e *archiveExtractor
+		r io.Reader
+	}
+
+	// countingReader counts the bytes read from the underlying reader.
+	countingReader struct {
+		r io.Reader
+		n int64
+	}
+
+	// dirArchiveSink extracts the entries into a directory.
+	dirArchiveSink struct {
+		root string
+	}
+
+	// memArchiveSink extracts the entries into memory.
+	memArchiveSink struct {
+		fsys *memFS
+	}
+
+	// memFS is a read-only in-memory file system returned by the archive helpers.
+	// Unlike fstest.MapFS, callers can't modify it, file contents are never exposed directly.
+	memFS struct {
+		files map[string]*memFile
+	}
+
+	// memFile is a file or directory of memFS, it implements fs.FileInfo and fs.DirEntry.
+	memFile struct {
+		name     string
+		data     []byte
+		mode     fs.FileMode
+		modTime  time.Time
+		children []string
+	}
+
+	// openMemFile is an open regular file of memFS.
+	openMemFile struct {
+		*bytes.Reader
+		f *memFile
+	}
+
+	// openMemDir is an open directory of memFS.
+	openMemDir struct {
+		f       *memFile
+		fsys    *memFS
+		entries []string
+		offset  int
+	}
+)
+
+// WithArchiveMaxSize sets the max total uncompressed size in bytes, zero or negative disables the limit.
+// Defaults to DefaultArchiveMaxSize.
+func WithArchiveMaxSize(n int64) ArchiveOption {
+	return func(o *archiveOptions) {
+		o.maxSize = n
+	}
+}
+
+// WithArchiveMaxFiles sets the max number of entries, zero or negative disables the limit.
+// Defaults to DefaultArchiveMaxFiles.
+func WithArchiveMaxFiles(n int) ArchiveOption {
+	return func(o *archiveOptions) {
+		o.maxFiles = n
+	}
+}
+
+// WithArchiveMaxRatio sets the max ratio of the uncompressed to the compressed size,
+// zero or negative disables the limit. Defaults to DefaultArchiveMaxRatio.
+func WithArchiveMaxRatio(n int64) ArchiveOption {
+	return func(o *archiveOptions) {
+		o.maxRatio = n
+	}
+}
+
+// newArchiveOptions returns archive options with defaults applied.
+func newArchiveOptions(opts ...ArchiveOption) *archiveOptions {
+	o := &archiveOptions{
+		maxSize:  DefaultArchiveMaxSize,
+		maxFiles: DefaultArchiveMaxFiles,
+		maxRatio: DefaultArchiveMaxRatio,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// ExtractZip extracts the zip archive into the directory, which is created if it doesn't exist.
+// Entries with absolute paths or paths escaping the directory and symlinks pointing outside of it
+// are rejected with ErrUnsafeArchivePath. A symlink target may only step up with ".." out of
+// real directories, not out of other symlinks or paths missing at that point.
+// The total uncompressed size, the number of entries and the compression ratio are limited
+// (see WithArchiveMaxSize, WithArchiveMaxFiles and WithArchiveMaxRatio),
+// the limits are checked against the extracted data, not the sizes declared in the archive.
+// Special files (devices, pipes, hard links) are skipped. Extracted files are left in place on error.
+func ExtractZip(r io.ReaderAt, size int64, dir string, opts ...ArchiveOption) error {
+	sink, err := newDirArchiveSink(dir)
+	if err != nil {
+		return err
+	}
+	return extractZip(r, size, sink, newArchiveOptions(opts...))
+}
+
+// ExtractZipFS extracts the zip archive into memory and returns it as a read-only file system.
+// Symlinks are validated but not extracted. See ExtractZip for the limits.
+func ExtractZipFS(r io.ReaderAt, size int64, opts ...ArchiveOption) (fs.FS, error) {
+	sink := &memArchiveSink{fsys: newMemFS()}
+	if err := extractZip(r, size, sink, newArchiveOptions(opts...)); err != nil {
+		return nil, err
+	}
+	return sink.fsys, nil
+}
+
+// ExtractTar extracts the tar archive into the directory, which is created if it doesn't exist.
+// Gzip compressed archives (tar.gz, tgz) are detected and decompressed automatically.
+// See ExtractZip for the security checks and limits.
+func ExtractTar(r io.Reader, dir string, opts ...ArchiveOption) error {
+	sink, err := newDirArchiveSink(dir)
+	if err != nil {
+		return err
+	}
+	return extractTar(r, sink, newArchiveOptions(opts...))
+}
+
+// ExtractTarFS extracts the tar or tar.gz archive into memory and returns it as a read-only file system.
+// Symlinks are validated but not extracted. See ExtractZip for the limits.
+func ExtractTarFS(r io.Reader, opts ...ArchiveOption) (fs.FS, error) {
+	sink := &memArchiveSink{fsys: newMemFS()}
+	if err := extractTar(r, sink, newArchiveOptions(opts...)); err != nil {
+		return nil, err
+	}
+	return sink.fsys, nil
+}
+
+// ExtractArchiveFile extracts the zip, tar or tar.gz archive file into the directory.
+// The format is detected from the content, ErrUnsupportedArchive is returned for other files.
+func ExtractArchiveFile(name, dir string, opts ...ArchiveOption) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer f.Close()
+
+	contentType, err := GetFileContentType(f)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to read archive: %w", err)
+	}
+
+	switch contentType {
+	case "application/zip":
+		info, err := f.Stat()
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+		return ExtractZip(f, info.Size(), dir, opts...)
+	case "application/x-tar", "application/gzip":
+		return ExtractTar(f, dir, opts...)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedArchive, contentType)
+	}
+}
+
+// extractZip extracts the zip archive into the sink.
+func extractZip(r io.ReaderAt, size int64, sink archiveSink, o *archiveOptions) error {
+	if r == nil {
+		return fmt.Errorf("invalid reader: %w", ErrInvalidReader)
+	}
+
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return wrapKind(ErrInvalidArchive, err)
+	}
+	if o.maxFiles > 0 && len(zr.File) > o.maxFiles {
+		return fmt.Errorf("%w: %d entries, max %d", ErrArchiveTooManyFiles, len(zr.File), o.maxFiles)
+	}
+
+	var compressed int64
+	e := &archiveExtractor{o: o, sink: sink, compressed: func() int64 { return compressed }}
+	for _, f := range zr.File {
+		// The declared sizes can't be trusted, the compressed data can't be larger than the archive.
+		if compressed += int64(f.CompressedSize64); compressed > size || compressed < 0 {
+			compressed = size
+		}
+		entry := archiveEntry{
+			name:    f.Name,
+			mode:    f.Mode(),
+			modTime: f.Modified,
+		}
+
+		err := e.extract(entry, func() (io.ReadCloser, error) {
+			return f.Open()
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// extractTar extracts the tar archive, optionally gzip compressed, into the sink.
+func extractTar(r io.Reader, sink archiveSink, o *archiveOptions) error {
+	if r == nil {
+		return fmt.Errorf("invalid reader: %w", ErrInvalidReader)
+	}
+
+	raw := &countingReader{r: r}
+	br := bufio.NewReader(raw)
+
+	var src io.Reader = br
+	if magic, _ := br.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return wrapKind(ErrInvalidArchive, err)
+		}
+		defer gz.Close()
+		src = gz
+	}
+
+	e := &archiveExtractor{o: o, sink: sink, compressed: func() int64 { return raw.n }}
+	tr := tar.NewReader(src)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return wrapKind(ErrInvalidArchive, err)
+		}
+
+		entry := archiveEntry{
+			name:    hdr.Name,
+			mode:    hdr.FileInfo().Mode(),
+			modTime: hdr.ModTime,
+			link:    hdr.Linkname,
+		}
+		if hdr.Typeflag == tar.TypeLink {
+			// Hard links are skipped as special files.
+			entry.mode |= fs.ModeIrregular
+		}
+
+		err = e.extract(entry, func() (io.ReadCloser, error) {
+			return io.NopCloser(tr), nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// extract validates the entry and stores it in the sink.
+func (e *archiveExtractor) extract(entry archiveEntry, open func() (io.ReadCloser, error)) error {
+	e.files++
+	if e.o.maxFiles > 0 && e.files > e.o.maxFiles {
+		return fmt.Errorf("%w: max %d", ErrArchiveTooManyFiles, e.o.maxFiles)
+	}
+
+	name, err := archiveEntryName(entry.name)
+	if err != nil {
+		return err
+	}
+	if name == "." {
+		return nil
+	}
+
+	switch {
+	case entry.mode.IsDir():
+		return e.sink.mkdir(name, entry.mode.Perm()|0o700)
+	case entry.mode&fs.ModeSymlink != 0:
+		target := entry.link
+		if target == "" {
+			// Zip stores the symlink target as the file content.
+			rc, err := open()
+			if err != nil {
+				return wrapKind(ErrInvalidArchive, fmt.Errorf("%s: %w", entry.name, err))
+			}
+			b, err := io.ReadAll(io.LimitReader(&archiveCounter{e: e, r: rc}, 4096))
+			rc.Close()
+			if err != nil {
+				return err
+			}
+			target = string(b)
+		}
+		if err := validateArchiveLink(name, target); err != nil {
+			return err
+		}
+		return e.sink.symlink(name, target)
+	case entry.mode.IsRegular():
+		rc, err := open()
+		if err != nil {
+			return wrapKind(ErrInvalidArchive, fmt.Errorf("%s: %w", entry.name, err))
+		}
+		defer rc.Close()
+
+		err = e.sink.create(name, entry.mode.Perm()|0o600, entry.modTime, &archiveCounter{e: e, r: rc})
+		if err != nil {
+			return fmt.Errorf("failed to extract %s: %w", name, err)
+		}
+		return nil
+	default:
+		return nil
+	}
+}
+
+// Read implements io.Reader.
+func (c *archiveCounter) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.e.written += int64(n)
+
+	if c.e.o.maxSize > 0 && c.e.written > c.e.o.maxSize {
+		return n, fmt.Errorf("%w: max %d bytes", ErrArchiveTooLarge, c.e.o.maxSize)
+	}
+	if c.e.o.maxRatio > 0 && c.e.written > archiveRatioMinSize {
+		if compressed := c.e.compressed(); compressed <= 0 || c.e.written/compressed > c.e.o.maxRatio {
+			return n, fmt.Errorf("%w: max %d", ErrArchiveCompressionRatio, c.e.o.maxRatio)
+		}
+	}
+
+	return n, err
+}
+
+// Read implements io.Reader.
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// archiveEntryName returns the cleaned slash-separated entry name,
+// or ErrUnsafeArchivePath if it's absolute or escapes the root.
+func archiveEntryName(name string) (string, error) {
+	slashed := strings.ReplaceAll(name, `\`, "/")
+	if path.IsAbs(slashed) || filepath.VolumeName(name) != "" || (len(slashed) > 1 && slashed[1] == ':') {
+		return "", fmt.Errorf("%w: %s", ErrUnsafeArchivePath, name)
+	}
+
+	cleaned := path.Clean(slashed)
+	if cleaned == ".." || strings.HasPrefix(cleaned, "../") || !fs.ValidPath(cleaned) {
+		return "", fmt.Errorf("%w: %s", ErrUnsafeArchivePath, name)
+	}
+
+	return cleaned, nil
+}
+
+// validateArchiveLink checks that the symlink target is relative and points inside the root.
+func validateArchiveLink(name, target string) error {
+	slashed := strings.ReplaceAll(target, `\`, "/")
+	if target == "" || path.IsAbs(slashed) || filepath.IsAbs(target) || filepath.VolumeName(target) != "" {
+		return fmt.Errorf("%w: %s -> %s", ErrUnsafeArchivePath, name, target)
+	}
+
+	resolved := path.Join(path.Dir(name), slashed)
+	if resolved == ".." || strings.HasPrefix(resolved, "../") {
+		return fmt.Errorf("%w: %s -> %s", ErrUnsafeArchivePath, name, target)
+	}
+
+	return nil
+}
+
+// newDirArchiveSink creates the directory and returns the sink extracting into it.
+func newDirArchiveSink(dir string) (*dirArchiveSink, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	root, err := filepath.Abs(dir)
+	if err == nil {
+		root, err = filepath.EvalSymlinks(root)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve directory: %w", err)
+	}
+
+	return &dirArchiveSink{root: root}, nil
+}
+
+// path returns the file system path of the entry. Its parent directory is created and verified
+// to be inside the root after resolving symlinks, so previously extracted links can't be used to escape.
+func (s *dirArchiveSink) path(name string) (string, error) {
+	p := filepath.Join(s.root, filepath.FromSlash(name))
+	parent := filepath.Dir(p)
+
+	// Verify the nearest existing ancestor before creating missing directories.
+	existing := parent
+	for {
+		if _, err := os.Lstat(existing); err == nil || existing == s.root {
+			break
+		}
+		existing = filepath.Dir(existing)
+	}
+	if err := s.checkInside(existing, name); err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(parent, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := s.checkInside(parent, name); err != nil {
+		return "", err
+	}
+
+	return p, nil
+}
+
+// checkInside returns ErrUnsafeArchivePath if the path resolves outside of the root.
+func (s *dirArchiveSink) checkInside(p, name string) error {
+	real, err := filepath.EvalSymlinks(p)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path: %w", err)
+	}
+	if rel, err := filepath.Rel(s.root, real); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("%w: %s", ErrUnsafeArchivePath, name)
+	}
+	return nil
+}
+
+// removeExisting removes the file or symlink at the path, so it's not written through.
+func (s *dirArchiveSink) removeExisting(p string) error {
+	info, err := os.Lstat(p)
+	if err != nil {
+		return nil
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%w: %s is a directory", ErrInvalidArchive, p)
+	}
+	return os.Remove(p)
+}
+
+func (s *dirArchiveSink) mkdir(name string, perm fs.FileMode) error {
+	p, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(p, perm); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	return s.checkInside(p, name)
+}
+
+func (s *dirArchiveSink) create(name string, perm fs.FileMode, modTime time.Time, r io.Reader) error {
+	p, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	if err := s.removeExisting(p); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if !modTime.IsZero() {
+		_ = os.Chtimes(p, modTime, modTime)
+	}
+	return nil
+}
+
+func (s *dirArchiveSink) symlink(name, target string) error {
+	p, err := s.path(name)
+	if err != nil {
+		return err
+	}
+
+	// The lexical check is not enough if the parent is a symlink itself, resolve the target from the real parent.
+	parent, err := filepath.EvalSymlinks(filepath.Dir(p))
+	if err != nil {
+		return fmt.Errorf("failed to resolve path: %w", err)
+	}
+	if err := s.checkLinkTarget(parent, name, target); err != nil {
+		return err
+	}
+
+	if err := s.removeExisting(p); err != nil {
+		return err
+	}
+	if err := os.Symlink(target, p); err != nil {
+		return err
+	}
+
+	// Dangling links are allowed, the target may be extracted later.
+	if _, err := os.Stat(p); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err := s.checkInside(p, name); err != nil {
+		_ = os.Remove(p)
+		return err
+	}
+	return nil
+}
+
+// checkLinkTarget walks the target from the real parent directory the way the OS resolves it.
+// The OS follows a symlink before applying "..", so "l/../x" is not "x" if l is a link.
+// That's why ".." may only follow real directories: they can't be replaced by links later,
+// while a missing component can.
+func (s *dirArchiveSink) checkLinkTarget(parent, name, target string) error {
+	cur, resolved := parent, true
+	for _, elem := range strings.Split(strings.ReplaceAll(target, `\`, "/"), "/") {
+		switch elem {
+		case "", ".":
+			continue
+		case "..":
+			if !resolved {
+				return fmt.Errorf("%w: %s -> %s", ErrUnsafeArchivePath, name, target)
+			}
+			cur = filepath.Dir(cur)
+			if rel, err := filepath.Rel(s.root, cur); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				return fmt.Errorf("%w: %s -> %s", ErrUnsafeArchivePath, name, target)
+			}
+		default:
+			cur = filepath.Join(cur, elem)
+			if info, err := os.Lstat(cur); err != nil || !info.IsDir() {
+				resolved = false
+			}
+		}
+	}
+	return nil
+}
+
+func (s *memArchiveSink) mkdir(name string, perm fs.FileMode) error {
+	return s.fsys.add(name, &memFile{mode: fs.ModeDir | perm})
+}
+
+func (s *memArchiveSink) create(name string, perm fs.FileMode, modTime time.Time, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return s.fsys.add(name, &memFile{data: data, mode: perm, modTime: modTime})
+}
+
+func (s *memArchiveSink) symlink(name, target string) error {
+	// Symlinks are not supported by the in-memory file system.
+	return nil
+}
+
+func newMemFS() *memFS {
+	return &memFS{files: map[string]*memFile{
+		".": {name: ".", mode: fs.ModeDir | 0o755},
+	}}
+}
+
+// add adds the file to the file system, missing parent directories are created.
+// An existing directory keeps its content, an existing file is replaced.
+func (m *memFS) add(name string, f *memFile) error {
+	f.name = path.Base(name)
+
+	if existing, ok := m.files[name]; ok {
+		if existing.mode.IsDir() != f.mode.IsDir() {
+			return fmt.Errorf("%w: %s: file type conflict", ErrInvalidArchive, name)
+		}
+		f.children = existing.children
+		m.files[name] = f
+		return nil
+	}
+
+	dir := path.Dir(name)
+	parent, ok := m.files[dir]
+	if !ok {
+		if err := m.add(dir, &memFile{mode: fs.ModeDir | 0o755}); err != nil {
+			return err
+		}
+		parent = m.files[dir]
+	}
+	if !parent.mode.IsDir() {
+		return fmt.Errorf("%w: %s: parent is not a directory", ErrInvalidArchive, name)
+	}
+
+	parent.children = append(parent.children, name)
+	m.files[name] = f
+	return nil
+}
+
+// Open implements fs.FS.
+func (m *memFS) Open(name string) (fs.File, error) {
+	f, err := m.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.mode.IsDir() {
+		entries := append([]string(nil), f.children...)
+		sort.Strings(entries)
+		return &openMemDir{f: f, fsys: m, entries: entries}, nil
+	}
+	return &openMemFile{Reader: bytes.NewReader(f.data), f: f}, nil
+}
+
+// ReadFile implements fs.ReadFileFS, the returned slice is a copy.
+func (m *memFS) ReadFile(name string) ([]byte, error) {
+	f, err := m.lookup("read", name)
+	if err != nil {
+		return nil, err
+	}
+	if f.mode.IsDir() {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrInvalid}
+	}
+	return append([]byte(nil), f.data...), nil
+}
+
+// Stat implements fs.StatFS.
+func (m *memFS) Stat(name string) (fs.FileInfo, error) {
+	return m.lookup("stat", name)
+}
+
+func (m *memFS) lookup(op, name string) (*memFile, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	f, ok := m.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return f, nil
+}
+
+func (f *memFile) Name() string               { return f.name }
+func (f *memFile) Size() int64                { return int64(len(f.data)) }
+func (f *memFile) Mode() fs.FileMode          { return f.mode }
+func (f *memFile) ModTime() time.Time         { return f.modTime }
+func (f *memFile) IsDir() bool                { return f.mode.IsDir() }
+func (f *memFile) Sys() any                   { return nil }
+func (f *memFile) Type() fs.FileMode          { return f.mode.Type() }
+func (f *memFile) Info() (fs.FileInfo, error) { return f, nil }
+
+func (f *openMemFile) Stat() (fs.FileInfo, error) { return f.f, nil }
+func (f *openMemFile) Close() error               { return nil }
+
+func (d *openMemDir) Stat() (fs.FileInfo, error) { return d.f, nil }
+func (d *openMemDir) Close() error               { return nil }
+
+func (d *openMemDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.f.name, Err: fs.ErrInvalid}
+}
+
+// ReadDir implements fs.ReadDirFile.
+func (d *openMemDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if n > 0 && len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > 0 && n < len(rest) {
+		rest = rest[:n]
+	}
+	d.offset += len(rest)
+
+	entries := make([]fs.DirEntry, len(rest))
+	for i, name := range rest {
+		entries[i] = d.fsys.files[name]
+	}
+	return entries, nil
+}
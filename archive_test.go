@@ -0,0 +1,362 @@
+package utils_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/dmitrymomot/go-utils"
+)
+
+type archiveFile struct {
+	name string
+	body string
+	link string
+	dir  bool
+}
+
+func TestExtractZip(t *testing.T) {
+	t.Run("valid archive", func(t *testing.T) {
+		data := buildZip(t, []archiveFile{
+			{name: "docs/", dir: true},
+			{name: "docs/readme.txt", body: "hello"},
+			{name: `win\path.txt`, body: "windows"},
+			{name: "docs/link.txt", link: "readme.txt"},
+			{name: "win/up.txt", link: "../docs/readme.txt"},
+		})
+
+		dir := filepath.Join(t.TempDir(), "out")
+		if err := utils.ExtractZip(bytes.NewReader(data), int64(len(data)), dir); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		assertFileContent(t, filepath.Join(dir, "docs", "readme.txt"), "hello")
+		assertFileContent(t, filepath.Join(dir, "win", "path.txt"), "windows")
+		assertFileContent(t, filepath.Join(dir, "docs", "link.txt"), "hello")
+		assertFileContent(t, filepath.Join(dir, "win", "up.txt"), "hello")
+	})
+
+	t.Run("in memory", func(t *testing.T) {
+		data := buildZip(t, []archiveFile{
+			{name: "a/b/c.txt", body: "nested"},
+			{name: "a/link", link: "b/c.txt"},
+		})
+
+		fsys, err := utils.ExtractZipFS(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		b, err := fs.ReadFile(fsys, "a/b/c.txt")
+		if err != nil || string(b) != "nested" {
+			t.Errorf("got %q, %v", b, err)
+		}
+		if _, err := fs.Stat(fsys, "a/link"); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("symlinks must be skipped, got %v", err)
+		}
+		if _, ok := fsys.(fstest.MapFS); ok {
+			t.Errorf("the file system must be read-only")
+		}
+		if err := fstest.TestFS(fsys, "a/b/c.txt"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	unsafe := []struct {
+		name  string
+		files []archiveFile
+	}{
+		{"zip slip", []archiveFile{{name: "../evil.txt", body: "x"}}},
+		{"nested zip slip", []archiveFile{{name: "a/../../evil.txt", body: "x"}}},
+		{"windows zip slip", []archiveFile{{name: `..\evil.txt`, body: "x"}}},
+		{"absolute path", []archiveFile{{name: "/etc/evil", body: "x"}}},
+		{"windows absolute path", []archiveFile{{name: `C:\evil.txt`, body: "x"}}},
+		{"absolute symlink", []archiveFile{{name: "link", link: "/etc/passwd"}}},
+		{"escaping symlink", []archiveFile{{name: "a/link", link: "../../etc/passwd"}}},
+		{"symlink through symlinked dir", []archiveFile{
+			{name: "a", link: "."},
+			{name: "a/link", link: "../evil"},
+		}},
+		{"dot dot after symlink", []archiveFile{
+			{name: "l", link: "."},
+			{name: "u", link: "l/../evil.txt"},
+		}},
+		{"dot dot after missing dir", []archiveFile{
+			{name: "d/", dir: true},
+			{name: "d/u", link: "x/../../evil.txt"},
+		}},
+	}
+
+	for _, tt := range unsafe {
+		t.Run(tt.name, func(t *testing.T) {
+			data := buildZip(t, tt.files)
+			root := t.TempDir()
+			dir := filepath.Join(root, "out")
+
+			err := utils.ExtractZip(bytes.NewReader(data), int64(len(data)), dir)
+			if !errors.Is(err, utils.ErrUnsafeArchivePath) {
+				t.Fatalf("got error %v, want %v", err, utils.ErrUnsafeArchivePath)
+			}
+			if _, err := os.Lstat(filepath.Join(root, "evil.txt")); err == nil {
+				t.Errorf("file was written outside of the destination")
+			}
+		})
+	}
+
+	t.Run("write through existing symlink", func(t *testing.T) {
+		root := t.TempDir()
+		data := buildZip(t, []archiveFile{
+			{name: "link", link: "target.txt"},
+			{name: "link", body: "replaced"},
+		})
+
+		dir := filepath.Join(root, "out")
+		if err := utils.ExtractZip(bytes.NewReader(data), int64(len(data)), dir); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		info, err := os.Lstat(filepath.Join(dir, "link"))
+		if err != nil || info.Mode()&fs.ModeSymlink != 0 {
+			t.Errorf("symlink must be replaced by a regular file: %v, %v", info, err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "target.txt")); err == nil {
+			t.Errorf("file was written through the symlink")
+		}
+	})
+
+	t.Run("limits", func(t *testing.T) {
+		bomb := buildZip(t, []archiveFile{{name: "zeros", body: strings.Repeat("\x00", 4<<20)}})
+		files := buildZip(t, []archiveFile{{name: "a", body: "1"}, {name: "b", body: "2"}, {name: "c", body: "3"}})
+
+		tests := []struct {
+			name string
+			data []byte
+			opts []utils.ArchiveOption
+			err  error
+		}{
+			{"compression ratio", bomb, nil, utils.ErrArchiveCompressionRatio},
+			{"total size", bomb, []utils.ArchiveOption{utils.WithArchiveMaxRatio(0), utils.WithArchiveMaxSize(1 << 20)}, utils.ErrArchiveTooLarge},
+			{"file count", files, []utils.ArchiveOption{utils.WithArchiveMaxFiles(2)}, utils.ErrArchiveTooManyFiles},
+			{"limits disabled", bomb, []utils.ArchiveOption{utils.WithArchiveMaxRatio(0)}, nil},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := utils.ExtractZipFS(bytes.NewReader(tt.data), int64(len(tt.data)), tt.opts...)
+				if !errors.Is(err, tt.err) {
+					t.Errorf("got error %v, want %v", err, tt.err)
+				}
+			})
+		}
+	})
+
+	t.Run("invalid archive", func(t *testing.T) {
+		if _, err := utils.ExtractZipFS(strings.NewReader("not a zip"), 9); !errors.Is(err, utils.ErrInvalidArchive) {
+			t.Errorf("got error %v, want %v", err, utils.ErrInvalidArchive)
+		}
+	})
+}
+
+func TestExtractTar(t *testing.T) {
+	files := []archiveFile{
+		{name: "./", dir: true},
+		{name: "./app/config.yml", body: "debug: true"},
+		{name: "./app/current", link: "config.yml"},
+	}
+
+	for _, compressed := range []bool{false, true} {
+		name := "tar"
+		if compressed {
+			name = "tar.gz"
+		}
+
+		t.Run(name, func(t *testing.T) {
+			data := buildTar(t, files, compressed)
+
+			dir := t.TempDir()
+			if err := utils.ExtractTar(bytes.NewReader(data), dir); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			assertFileContent(t, filepath.Join(dir, "app", "config.yml"), "debug: true")
+			assertFileContent(t, filepath.Join(dir, "app", "current"), "debug: true")
+
+			fsys, err := utils.ExtractTarFS(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if b, err := fs.ReadFile(fsys, "app/config.yml"); err != nil || string(b) != "debug: true" {
+				t.Errorf("got %q, %v", b, err)
+			}
+			if err := fstest.TestFS(fsys, "app/config.yml"); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+
+	t.Run("unsafe paths", func(t *testing.T) {
+		for _, files := range [][]archiveFile{
+			{{name: "../../evil.txt", body: "x"}},
+			{{name: "/tmp/evil.txt", body: "x"}},
+			{{name: "link", link: "../outside"}},
+			{{name: "l", link: "."}, {name: "u", link: "l/../outside"}},
+		} {
+			root := t.TempDir()
+			if err := os.WriteFile(filepath.Join(root, "outside"), []byte("secret"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			dir := filepath.Join(root, "out")
+			data := buildTar(t, files, true)
+			if err := utils.ExtractTar(bytes.NewReader(data), dir); !errors.Is(err, utils.ErrUnsafeArchivePath) {
+				t.Errorf("%s: got error %v, want %v", files[len(files)-1].name, err, utils.ErrUnsafeArchivePath)
+			}
+			if b, err := os.ReadFile(filepath.Join(dir, "u")); err == nil {
+				t.Errorf("link points outside of the destination: %q", b)
+			}
+		}
+	})
+
+	t.Run("hard links are skipped", func(t *testing.T) {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		_ = tw.WriteHeader(&tar.Header{Name: "passwd", Typeflag: tar.TypeLink, Linkname: "/etc/passwd"})
+		_ = tw.Close()
+
+		dir := t.TempDir()
+		if err := utils.ExtractTar(&buf, dir); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := os.Lstat(filepath.Join(dir, "passwd")); err == nil {
+			t.Errorf("hard link must be skipped")
+		}
+	})
+
+	t.Run("gzip bomb", func(t *testing.T) {
+		data := buildTar(t, []archiveFile{{name: "zeros", body: strings.Repeat("\x00", 8<<20)}}, true)
+		if _, err := utils.ExtractTarFS(bytes.NewReader(data)); !errors.Is(err, utils.ErrArchiveCompressionRatio) {
+			t.Errorf("got error %v, want %v", err, utils.ErrArchiveCompressionRatio)
+		}
+	})
+}
+
+func TestExtractArchiveFile(t *testing.T) {
+	dir := t.TempDir()
+	zipPath := filepath.Join(dir, "archive.bin")
+	if err := os.WriteFile(zipPath, buildZip(t, []archiveFile{{name: "a.txt", body: "zip"}}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tgzPath := filepath.Join(dir, "archive.tgz")
+	if err := os.WriteFile(tgzPath, buildTar(t, []archiveFile{{name: "b.txt", body: "tar"}}, true), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	txtPath := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(txtPath, []byte("plain text"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(dir, "out")
+	if err := utils.ExtractArchiveFile(zipPath, out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := utils.ExtractArchiveFile(tgzPath, out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertFileContent(t, filepath.Join(out, "a.txt"), "zip")
+	assertFileContent(t, filepath.Join(out, "b.txt"), "tar")
+
+	if err := utils.ExtractArchiveFile(txtPath, out); !errors.Is(err, utils.ErrUnsupportedArchive) {
+		t.Errorf("got error %v, want %v", err, utils.ErrUnsupportedArchive)
+	}
+}
+
+func buildZip(t *testing.T, files []archiveFile) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range files {
+		h := &zip.FileHeader{Name: f.name, Method: zip.Deflate}
+		switch {
+		case f.dir:
+			h.SetMode(fs.ModeDir | 0o755)
+		case f.link != "":
+			h.SetMode(fs.ModeSymlink | 0o777)
+		default:
+			h.SetMode(0o644)
+		}
+
+		w, err := zw.CreateHeader(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body := f.body
+		if f.link != "" {
+			body = f.link
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func buildTar(t *testing.T, files []archiveFile, compressed bool) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	var gz *gzip.Writer
+	tw := tar.NewWriter(&buf)
+	if compressed {
+		gz = gzip.NewWriter(&buf)
+		tw = tar.NewWriter(gz)
+	}
+
+	for _, f := range files {
+		h := &tar.Header{Name: f.name, Mode: 0o644, Size: int64(len(f.body)), Typeflag: tar.TypeReg}
+		switch {
+		case f.dir:
+			h.Typeflag, h.Mode, h.Size = tar.TypeDir, 0o755, 0
+		case f.link != "":
+			h.Typeflag, h.Linkname, h.Size = tar.TypeSymlink, f.link, 0
+		}
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(f.body)); err != nil && f.link == "" {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return buf.Bytes()
+}
+
+func assertFileContent(t *testing.T, path, expected string) {
+	t.Helper()
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Errorf("failed to read %s: %v", path, err)
+		return
+	}
+	if string(b) != expected {
+		t.Errorf("%s: got %q, want %q", path, b, expected)
+	}
+}
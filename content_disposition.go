@@ -0,0 +1,197 @@
+package utils
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// Content-Disposition types (RFC 6266).
+const (
+	DispositionAttachment = "attachment"
+	DispositionInline     = "inline"
+)
+
+// rfc5987AttrChars are the characters allowed unencoded in an extended parameter value (RFC 5987, attr-char).
+const rfc5987AttrChars = "!#$&+-.^_`|~"
+
+// ContentDisposition is a parsed Content-Disposition header.
+type ContentDisposition struct {
+	// Type is the lowercased disposition type, e.g. "attachment" or "inline".
+	Type string
+	// FileName is the decoded file name, filename* takes precedence over filename.
+	// It's sent by the server as is, so sanitize it before use, see SanitizeFileName.
+	FileName string
+	// Params contains all parameters with lowercased names, extended parameters
+	// (e.g. filename*) are decoded and stored without the asterisk.
+	Params map[string]string
+}
+
+// ParseContentDisposition parses the Content-Disposition header (RFC 6266).
+// Extended parameters (RFC 5987), e.g. filename* with a percent-encoded UTF-8 name, are decoded
+// from UTF-8 or ISO-8859-1 and take precedence over the plain ones. The parser is lenient:
+// unquoted values with spaces and malformed parameters are accepted or skipped the way browsers do.
+func ParseContentDisposition(header string) (ContentDisposition, error) {
+	typ, rest, _ := strings.Cut(header, ";")
+	typ = strings.ToLower(strings.TrimSpace(typ))
+	if typ == "" || strings.ContainsAny(typ, " \t\"=,") {
+		return ContentDisposition{}, fmt.Errorf("%w: %q", ErrInvalidContentDisposition, header)
+	}
+
+	cd := ContentDisposition{Type: typ, Params: make(map[string]string)}
+	extended := make(map[string]bool)
+
+	for {
+		rest = strings.TrimLeft(rest, "; \t")
+		if rest == "" {
+			break
+		}
+
+		eq := strings.IndexAny(rest, "=;")
+		if eq < 0 {
+			break
+		}
+		if rest[eq] == ';' {
+			// Parameter without value, skip it.
+			rest = rest[eq:]
+			continue
+		}
+
+		var value string
+		key := strings.ToLower(strings.TrimSpace(rest[:eq]))
+		rest = strings.TrimLeft(rest[eq+1:], " \t")
+
+		if strings.HasPrefix(rest, `"`) {
+			value, rest = consumeQuotedString(rest)
+		} else {
+			end := strings.Index(rest, ";")
+			if end < 0 {
+				end = len(rest)
+			}
+			value, rest = strings.TrimSpace(rest[:end]), rest[end:]
+		}
+
+		if name := strings.TrimSuffix(key, "*"); name != key {
+			if decoded, ok := decodeExtendedValue(value); ok {
+				cd.Params[name] = decoded
+				extended[name] = true
+			}
+			continue
+		}
+		if !extended[key] {
+			cd.Params[key] = value
+		}
+	}
+
+	cd.FileName = cd.Params["filename"]
+	return cd, nil
+}
+
+// FormatContentDisposition returns the Content-Disposition header value for the file name.
+// The file name is sanitized (see SanitizeFileName). A non-ASCII name is sent both as an ASCII
+// fallback in filename and as UTF-8 in filename* (RFC 6266, section 4.3), so it's displayed
+// correctly by all clients. The file name is omitted if it's empty.
+func FormatContentDisposition(dispositionType, fileName string) string {
+	dispositionType = strings.ToLower(strings.TrimSpace(dispositionType))
+	if dispositionType == "" {
+		dispositionType = DispositionAttachment
+	}
+	if strings.TrimSpace(fileName) == "" {
+		return dispositionType
+	}
+
+	name := SanitizeFileName(fileName)
+	fallback := SanitizeFileName(name, WithASCIIFileName())
+
+	var sb strings.Builder
+	sb.WriteString(dispositionType)
+	sb.WriteString(`; filename="`)
+	sb.WriteString(strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(fallback))
+	sb.WriteString(`"`)
+	if name != fallback {
+		sb.WriteString("; filename*=UTF-8''")
+		sb.WriteString(encodeExtendedValue(name))
+	}
+
+	return sb.String()
+}
+
+// AttachmentDisposition returns the Content-Disposition header value which makes
+// the client download the file with the given name.
+func AttachmentDisposition(fileName string) string {
+	return FormatContentDisposition(DispositionAttachment, fileName)
+}
+
+// InlineDisposition returns the Content-Disposition header value which makes
+// the client display the file, the name is used if the user saves it.
+func InlineDisposition(fileName string) string {
+	return FormatContentDisposition(DispositionInline, fileName)
+}
+
+// consumeQuotedString parses the quoted string at the beginning of s
+// and returns the unescaped value and the rest of s.
+// An unterminated string lasts until the end of s.
+func consumeQuotedString(s string) (string, string) {
+	var sb strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			return sb.String(), s[i+1:]
+		case '\\':
+			if i+1 < len(s) {
+				i++
+			}
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String(), ""
+}
+
+// decodeExtendedValue decodes the RFC 5987 value: charset'language'percent-encoded-value.
+func decodeExtendedValue(s string) (string, bool) {
+	parts := strings.SplitN(s, "'", 3)
+	if len(parts) != 3 {
+		return "", false
+	}
+
+	value, err := url.PathUnescape(parts[2])
+	if err != nil {
+		return "", false
+	}
+
+	switch strings.ToLower(parts[0]) {
+	case "utf-8", "us-ascii", "":
+		if !utf8.ValidString(value) {
+			return "", false
+		}
+		return value, true
+	case "iso-8859-1", "latin1":
+		// ISO-8859-1 bytes are the first 256 Unicode code points.
+		runes := make([]rune, len(value))
+		for i := 0; i < len(value); i++ {
+			runes[i] = rune(value[i])
+		}
+		return string(runes), true
+	default:
+		return "", false
+	}
+}
+
+// encodeExtendedValue percent-encodes all bytes except RFC 5987 attr-char.
+func encodeExtendedValue(s string) string {
+	const hex = "0123456789ABCDEF"
+
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < utf8.RuneSelf && (isASCIIAlnum(rune(c)) || strings.IndexByte(rfc5987AttrChars, c) >= 0) {
+			sb.WriteByte(c)
+			continue
+		}
+		sb.WriteByte('%')
+		sb.WriteByte(hex[c>>4])
+		sb.WriteByte(hex[c&0x0f])
+	}
+	return sb.String()
+}
@@ -0,0 +1,92 @@
+package utils_test
+
+import (
+	"errors"
+	"mime"
+	"testing"
+
+	"github.com/dmitrymomot/go-utils"
+)
+
+func TestParseContentDisposition(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		typ      string
+		fileName string
+	}{
+		{"quoted file name", `attachment; filename="report 2023.csv"`, "attachment", "report 2023.csv"},
+		{"token file name", `inline; filename=photo.jpg`, "inline", "photo.jpg"},
+		{"type only", `ATTACHMENT`, "attachment", ""},
+		{"escaped quotes", `attachment; filename="say \"hi\".txt"`, "attachment", `say "hi".txt`},
+		{"semicolon in quoted value", `attachment; filename="a;b.txt"; size=10`, "attachment", "a;b.txt"},
+		{"utf-8 extended", `attachment; filename*=UTF-8''na%C3%AFve%20file.txt`, "attachment", "naïve file.txt"},
+		{"extended takes precedence", `attachment; filename*=utf-8''%E2%82%AC%20rates.pdf; filename="EUR rates.pdf"`, "attachment", "€ rates.pdf"},
+		{"extended with language", `attachment; filename*=UTF-8'en'hello.txt`, "attachment", "hello.txt"},
+		{"iso-8859-1 extended", `attachment; filename*=iso-8859-1'en'%A3%20rates.txt`, "attachment", "£ rates.txt"},
+		{"unknown charset falls back", `attachment; filename="fallback.txt"; filename*=koi8-r''%C1.txt`, "attachment", "fallback.txt"},
+		{"unquoted with spaces", `attachment; filename=my file.pdf`, "attachment", "my file.pdf"},
+		{"parameter without value", `attachment; foo; filename="a.txt"`, "attachment", "a.txt"},
+		{"case insensitive names", `attachment; FileName="a.txt"`, "attachment", "a.txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cd, err := utils.ParseContentDisposition(tt.header)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cd.Type != tt.typ || cd.FileName != tt.fileName {
+				t.Errorf("got %q %q, want %q %q", cd.Type, cd.FileName, tt.typ, tt.fileName)
+			}
+		})
+	}
+
+	for _, header := range []string{"", "  ", `; filename="a.txt"`, `attachment file; filename=a`} {
+		if _, err := utils.ParseContentDisposition(header); !errors.Is(err, utils.ErrInvalidContentDisposition) {
+			t.Errorf("%q: got error %v, want %v", header, err, utils.ErrInvalidContentDisposition)
+		}
+	}
+}
+
+func TestFormatContentDisposition(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		expected string
+	}{
+		{"ascii name", utils.AttachmentDisposition("report.pdf"), `attachment; filename="report.pdf"`},
+		{"inline", utils.InlineDisposition("photo.jpg"), `inline; filename="photo.jpg"`},
+		{"no name", utils.AttachmentDisposition(""), "attachment"},
+		{"default type", utils.FormatContentDisposition("", "a.txt"), `attachment; filename="a.txt"`},
+		{"non-ascii name", utils.AttachmentDisposition("Crème brûlée.pdf"),
+			`attachment; filename="Creme brulee.pdf"; filename*=UTF-8''Cr%C3%A8me%20br%C3%BBl%C3%A9e.pdf`},
+		{"header injection", utils.AttachmentDisposition("a\r\nSet-Cookie: x=\"1\".txt"),
+			`attachment; filename="aSet-Cookie_ x=_1_.txt"`},
+		{"path stripped", utils.AttachmentDisposition("../../etc/passwd"), `attachment; filename="passwd"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.header != tt.expected {
+				t.Errorf("got %s, want %s", tt.header, tt.expected)
+			}
+		})
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		for _, name := range []string{"отчёт 2023.xlsx", "€ rates.pdf", "日本語.txt", "plain.txt"} {
+			header := utils.AttachmentDisposition(name)
+
+			cd, err := utils.ParseContentDisposition(header)
+			if err != nil || cd.FileName != name {
+				t.Errorf("%s: got %q, %v", header, cd.FileName, err)
+			}
+
+			// The standard library parser must accept the header too.
+			if _, params, err := mime.ParseMediaType(header); err != nil || params["filename"] != name {
+				t.Errorf("%s: mime.ParseMediaType got %q, %v", header, params["filename"], err)
+			}
+		}
+	})
+}
@@ -1,50 +1,151 @@
 package utils
 
 import (
+	_ "embed"
 	"errors"
 	"fmt"
+	"io"
 	"strings"
+	"sync"
 
 	"github.com/mcnijman/go-emailaddress"
+	"golang.org/x/net/idna"
 )
 
 var (
-	ErrInvalidEmailAddress = errors.New("invalid email address")
-	ErrInvalidIcanSuffix   = errors.New("invalid ICAN suffix")
+	ErrInvalidEmailAddress    = errors.New("invalid email address")
+	ErrInvalidIcanSuffix      = errors.New("invalid ICAN suffix")
+	ErrRoleEmailAddress       = errors.New("role-based email address")
+	ErrDisposableEmailAddress = errors.New("disposable email address")
 )
 
-// SanitizeEmail cleans email address from dots, dashes, etc
-func SanitizeEmail(s string) (string, error) {
-	s = strings.ToLower(strings.TrimSpace(s))
+// Role-based and reserved local parts, e.g. admin@, noreply@, postmaster@, one per line.
+//
+//go:embed data/role_email_local_parts.txt
+var roleEmailLocalPartsList string
 
-	email, err := emailaddress.Parse(s)
-	if err != nil {
-		return "", ErrInvalidEmailAddress
+// defaultRoleEmailChecker is used by the package level helpers.
+var defaultRoleEmailChecker = NewRoleEmailChecker()
+
+// RoleEmailChecker detects role-based and reserved email addresses, e.g. admin@, noreply@, postmaster@.
+// It's safe for concurrent use.
+type RoleEmailChecker struct {
+	mu    sync.RWMutex
+	parts map[string]struct{}
+}
+
+// NewRoleEmailChecker returns a new checker seeded with the embedded list of role-based local parts.
+func NewRoleEmailChecker() *RoleEmailChecker {
+	c := &RoleEmailChecker{parts: make(map[string]struct{})}
+
+	// The embedded list is a plain string, so reading it never fails.
+	_ = c.Load(strings.NewReader(roleEmailLocalPartsList))
+
+	return c
+}
+
+// Add extends the list of role-based local parts.
+func (c *RoleEmailChecker) Add(parts ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, p := range parts {
+		if p = normalizeRoleLocalPart(p); p != "" {
+			c.parts[p] = struct{}{}
+		}
 	}
+}
 
-	username := email.LocalPart
-	domain := email.Domain
+// Load reads additional role-based local parts from the given reader.
+// The list must contain one local part per line, empty lines and lines starting with "#" are ignored.
+func (c *RoleEmailChecker) Load(r io.Reader) error {
+	parts, err := readListLines(r)
+	if err != nil {
+		return fmt.Errorf("failed to load role email local parts: %w", err)
+	}
+	c.Add(parts...)
+	return nil
+}
 
-	if strings.Contains(username, "+") {
-		p := strings.Split(username, "+")
-		username = p[0]
+// IsRole reports whether the local part of the email address is role-based or reserved.
+// "+tags" and separators are ignored, so "no-reply+bounce@example.com"
+// and "no.reply@example.com" are role accounts too.
+func (c *RoleEmailChecker) IsRole(email string) bool {
+	i := strings.LastIndex(email, "@")
+	if i <= 0 {
+		return false
 	}
 
-	if strings.Contains(username, ".") {
-		p := strings.Split(username, ".")
-		username = strings.Join(p, "")
+	local := normalizeRoleLocalPart(email[:i])
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	_, ok := c.parts[local]
+	return ok
+}
+
+// SanitizeEmail lowercases the email address and normalizes its local part
+// according to the provider rule registered for the domain (see RegisterEmailProviderRule).
+// Addresses at domains without a rule are only lowercased.
+// Internationalized addresses are supported: the local part is NFC normalized
+// and the domain is returned in Unicode form (see ParseInternationalEmail).
+func SanitizeEmail(s string) (string, error) {
+	email, err := ParseInternationalEmail(s)
+	if err != nil {
+		return "", ErrInvalidEmailAddress
 	}
 
-	if strings.Contains(username, "-") {
-		p := strings.Split(username, "-")
-		username = strings.Join(p, "")
+	username, domain := normalizeEmailParts(email.LocalPart, email.ASCIIDomain)
+	if d, err := idna.Lookup.ToUnicode(domain); err == nil {
+		domain = d
 	}
 
 	result := fmt.Sprintf("%s@%s", username, domain)
 
-	if err := email.ValidateIcanSuffix(); err != nil {
+	suffix := emailaddress.EmailAddress{LocalPart: username, Domain: email.ASCIIDomain}
+	if err := suffix.ValidateIcanSuffix(); err != nil {
 		return result, ErrInvalidIcanSuffix
 	}
 
 	return result, nil
 }
+
+// SanitizePersonalEmail sanitizes the email address with SanitizeEmail
+// and additionally returns ErrRoleEmailAddress if the address is a role account.
+func SanitizePersonalEmail(s string) (string, error) {
+	result, err := SanitizeEmail(s)
+	if err != nil {
+		return result, err
+	}
+
+	if IsRoleEmail(result) {
+		return result, ErrRoleEmailAddress
+	}
+
+	return result, nil
+}
+
+// IsRoleEmail reports whether the local part of the email address is role-based or reserved,
+// e.g. admin@, noreply@, postmaster@, using the default role list. See RoleEmailChecker.IsRole.
+func IsRoleEmail(email string) bool {
+	return defaultRoleEmailChecker.IsRole(email)
+}
+
+// AddRoleEmailLocalParts extends the default list of role-based local parts.
+func AddRoleEmailLocalParts(parts ...string) {
+	defaultRoleEmailChecker.Add(parts...)
+}
+
+// LoadRoleEmailLocalParts reads additional role-based local parts from the given reader to the default list.
+// The list must contain one local part per line, empty lines and lines starting with "#" are ignored.
+func LoadRoleEmailLocalParts(r io.Reader) error {
+	return defaultRoleEmailChecker.Load(r)
+}
+
+// normalizeRoleLocalPart lowercases the local part and removes the "+tag" and separators.
+func normalizeRoleLocalPart(s string) string {
+	s = strings.ToLower(strings.Trim(strings.TrimSpace(s), `"`))
+	s = strings.SplitN(s, "+", 2)[0]
+	return strings.NewReplacer(".", "", "-", "", "_", "").Replace(s)
+}
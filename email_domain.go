@@ -0,0 +1,218 @@
+package utils
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/idna"
+)
+
+// EmailDomainType is a classification of an email address domain.
+type EmailDomainType int
+
+// Predefined email domain types
+const (
+	EmailDomainBusiness EmailDomainType = iota
+	EmailDomainFree
+	EmailDomainDisposable
+)
+
+// String returns the name of the email domain type.
+func (t EmailDomainType) String() string {
+	switch t {
+	case EmailDomainFree:
+		return "free"
+	case EmailDomainDisposable:
+		return "disposable"
+	default:
+		return "business"
+	}
+}
+
+// Embedded domain lists, one domain per line.
+var (
+	//go:embed data/disposable_email_domains.txt
+	disposableEmailDomainsList string
+	//go:embed data/free_email_domains.txt
+	freeEmailDomainsList string
+)
+
+// defaultEmailDomainChecker is used by the package level helpers.
+var defaultEmailDomainChecker = NewEmailDomainChecker()
+
+// EmailDomainChecker classifies email addresses as disposable, free provider or business.
+// It's safe for concurrent use.
+type EmailDomainChecker struct {
+	mu         sync.RWMutex
+	disposable map[string]struct{}
+	free       map[string]struct{}
+}
+
+// NewEmailDomainChecker returns a new checker seeded with the embedded domain lists.
+func NewEmailDomainChecker() *EmailDomainChecker {
+	c := &EmailDomainChecker{
+		disposable: make(map[string]struct{}),
+		free:       make(map[string]struct{}),
+	}
+
+	// Embedded lists are plain strings, so reading them never fails.
+	_ = c.LoadDisposable(strings.NewReader(disposableEmailDomainsList))
+	_ = c.LoadFree(strings.NewReader(freeEmailDomainsList))
+
+	return c
+}
+
+// AddDisposable adds domains to the disposable list.
+func (c *EmailDomainChecker) AddDisposable(domains ...string) {
+	c.add(c.disposable, domains...)
+}
+
+// AddFree adds domains to the free provider list.
+func (c *EmailDomainChecker) AddFree(domains ...string) {
+	c.add(c.free, domains...)
+}
+
+// LoadDisposable reads additional disposable domains from the given reader.
+// The list must contain one domain per line, empty lines and lines starting with "#" are ignored.
+func (c *EmailDomainChecker) LoadDisposable(r io.Reader) error {
+	domains, err := readListLines(r)
+	if err != nil {
+		return fmt.Errorf("failed to load disposable domains: %w", err)
+	}
+	c.AddDisposable(domains...)
+	return nil
+}
+
+// LoadFree reads additional free provider domains from the given reader.
+// The list must contain one domain per line, empty lines and lines starting with "#" are ignored.
+func (c *EmailDomainChecker) LoadFree(r io.Reader) error {
+	domains, err := readListLines(r)
+	if err != nil {
+		return fmt.Errorf("failed to load free provider domains: %w", err)
+	}
+	c.AddFree(domains...)
+	return nil
+}
+
+// ClassifyDomain returns the type of the given domain.
+// Subdomains of a listed domain are classified the same way as the listed domain.
+func (c *EmailDomainChecker) ClassifyDomain(domain string) EmailDomainType {
+	domain = strings.Trim(strings.ToLower(strings.TrimSpace(domain)), ".")
+	if d, err := idna.Lookup.ToASCII(domain); err == nil {
+		domain = d
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for d := domain; d != ""; d = parentDomain(d) {
+		if _, ok := c.disposable[d]; ok {
+			return EmailDomainDisposable
+		}
+		if _, ok := c.free[d]; ok {
+			return EmailDomainFree
+		}
+	}
+
+	return EmailDomainBusiness
+}
+
+// Classify returns the type of the given email address domain.
+func (c *EmailDomainChecker) Classify(email string) (EmailDomainType, error) {
+	e, err := ParseInternationalEmail(email)
+	if err != nil {
+		return EmailDomainBusiness, ErrInvalidEmailAddress
+	}
+
+	return c.ClassifyDomain(e.ASCIIDomain), nil
+}
+
+// IsDisposable reports whether the email address belongs to a disposable domain.
+func (c *EmailDomainChecker) IsDisposable(email string) bool {
+	t, err := c.Classify(email)
+	return err == nil && t == EmailDomainDisposable
+}
+
+// IsFree reports whether the email address belongs to a free email provider.
+func (c *EmailDomainChecker) IsFree(email string) bool {
+	t, err := c.Classify(email)
+	return err == nil && t == EmailDomainFree
+}
+
+func (c *EmailDomainChecker) add(list map[string]struct{}, domains ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, d := range domains {
+		d = strings.Trim(strings.ToLower(strings.TrimSpace(d)), ".")
+		if d != "" {
+			list[d] = struct{}{}
+		}
+	}
+}
+
+// ClassifyEmail returns the type of the email address domain using the embedded lists.
+func ClassifyEmail(email string) (EmailDomainType, error) {
+	return defaultEmailDomainChecker.Classify(email)
+}
+
+// IsDisposableEmail reports whether the email address belongs to a disposable domain.
+func IsDisposableEmail(email string) bool {
+	return defaultEmailDomainChecker.IsDisposable(email)
+}
+
+// IsFreeEmail reports whether the email address belongs to a free email provider.
+func IsFreeEmail(email string) bool {
+	return defaultEmailDomainChecker.IsFree(email)
+}
+
+// LoadDisposableEmailDomains adds disposable domains from the reader to the default checker.
+func LoadDisposableEmailDomains(r io.Reader) error {
+	return defaultEmailDomainChecker.LoadDisposable(r)
+}
+
+// LoadFreeEmailDomains adds free provider domains from the reader to the default checker.
+func LoadFreeEmailDomains(r io.Reader) error {
+	return defaultEmailDomainChecker.LoadFree(r)
+}
+
+// readListLines reads a list of values, one per line.
+// Empty lines and lines starting with "#" are skipped.
+func readListLines(r io.Reader) ([]string, error) {
+	if r == nil {
+		return nil, ErrInvalidReader
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+// parentDomain returns the domain without its first label, or an empty string.
+func parentDomain(domain string) string {
+	i := strings.Index(domain, ".")
+	if i < 0 {
+		return ""
+	}
+	parent := domain[i+1:]
+	// Stop at the top level domain, it's never listed.
+	if !strings.Contains(parent, ".") {
+		return ""
+	}
+	return parent
+}
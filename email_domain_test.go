@@ -0,0 +1,89 @@
+package utils_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dmitrymomot/go-utils"
+)
+
+func TestClassifyEmail(t *testing.T) {
+	tests := []struct {
+		name    string
+		email   string
+		want    utils.EmailDomainType
+		wantErr error
+	}{
+		{"business", "john@acme.io", utils.EmailDomainBusiness, nil},
+		{"free", "john@gmail.com", utils.EmailDomainFree, nil},
+		{"free uppercase", " John@Yahoo.COM ", utils.EmailDomainFree, nil},
+		{"disposable", "john@mailinator.com", utils.EmailDomainDisposable, nil},
+		{"disposable subdomain", "john@inbox.yopmail.com", utils.EmailDomainDisposable, nil},
+		{"invalid", "john.mailinator.com", utils.EmailDomainBusiness, utils.ErrInvalidEmailAddress},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := utils.ClassifyEmail(tt.email)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ClassifyEmail() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ClassifyEmail() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmailDomainChecker(t *testing.T) {
+	t.Run("load lists", func(t *testing.T) {
+		c := utils.NewEmailDomainChecker()
+		list := "# custom list\n\nthrowaway.test\n  Burner.Example  \n"
+		if err := c.LoadDisposable(strings.NewReader(list)); err != nil {
+			t.Fatalf("LoadDisposable() error = %v", err)
+		}
+		if err := c.LoadFree(strings.NewReader("freemail.example\n")); err != nil {
+			t.Fatalf("LoadFree() error = %v", err)
+		}
+
+		if !c.IsDisposable("a@throwaway.test") {
+			t.Error("expected throwaway.test to be disposable")
+		}
+		if !c.IsDisposable("a@burner.example") {
+			t.Error("expected burner.example to be disposable")
+		}
+		if !c.IsFree("a@freemail.example") {
+			t.Error("expected freemail.example to be free")
+		}
+		if c.IsFree("a@throwaway.test") {
+			t.Error("expected throwaway.test not to be free")
+		}
+	})
+
+	t.Run("lists are isolated", func(t *testing.T) {
+		c := utils.NewEmailDomainChecker()
+		c.AddDisposable("isolated.example")
+		if utils.IsDisposableEmail("a@isolated.example") {
+			t.Error("expected default checker not to be affected")
+		}
+	})
+
+	t.Run("nil reader", func(t *testing.T) {
+		c := utils.NewEmailDomainChecker()
+		if err := c.LoadFree(nil); !errors.Is(err, utils.ErrInvalidReader) {
+			t.Errorf("LoadFree() error = %v, want %v", err, utils.ErrInvalidReader)
+		}
+	})
+
+	t.Run("type names", func(t *testing.T) {
+		if utils.EmailDomainDisposable.String() != "disposable" {
+			t.Errorf("unexpected name %q", utils.EmailDomainDisposable.String())
+		}
+		if utils.EmailDomainFree.String() != "free" {
+			t.Errorf("unexpected name %q", utils.EmailDomainFree.String())
+		}
+		if utils.EmailDomainBusiness.String() != "business" {
+			t.Errorf("unexpected name %q", utils.EmailDomainBusiness.String())
+		}
+	})
+}
@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mcnijman/go-emailaddress"
+	"golang.org/x/net/idna"
+	"golang.org/x/text/unicode/norm"
+)
+
+// InternationalEmail is an email address with internationalized parts.
+type InternationalEmail struct {
+	// LocalPart is the lowercased, NFC normalized local part.
+	LocalPart string
+	// Domain is the domain in Unicode form (IDNA U-labels), e.g. "bücher.de".
+	Domain string
+	// ASCIIDomain is the domain in ASCII form (IDNA A-labels), e.g. "xn--bcher-kva.de".
+	ASCIIDomain string
+	// SMTPUTF8 is true if the local part contains non-ASCII characters,
+	// so the address can be delivered via SMTPUTF8 capable servers only (RFC 6531).
+	SMTPUTF8 bool
+}
+
+// String returns the address with the domain in Unicode form.
+func (e InternationalEmail) String() string {
+	return e.LocalPart + "@" + e.Domain
+}
+
+// ASCII returns the address with the domain in ASCII form.
+// The local part is kept as is, so the result is pure ASCII only if SMTPUTF8 is false.
+func (e InternationalEmail) ASCII() string {
+	return e.LocalPart + "@" + e.ASCIIDomain
+}
+
+// ParseInternationalEmail parses and validates an email address which may contain
+// an internationalized domain name (IDN) and a UTF-8 local part (RFC 6532).
+func ParseInternationalEmail(s string) (InternationalEmail, error) {
+	s = strings.TrimSpace(s)
+
+	i := strings.LastIndex(s, "@")
+	if i <= 0 || i == len(s)-1 || !utf8.ValidString(s) {
+		return InternationalEmail{}, ErrInvalidEmailAddress
+	}
+
+	local := norm.NFC.String(strings.ToLower(s[:i]))
+	domain := strings.ToLower(s[i+1:])
+
+	asciiDomain, unicodeDomain := domain, domain
+	if !strings.HasPrefix(domain, "[") {
+		var err error
+		if asciiDomain, err = idna.Lookup.ToASCII(domain); err != nil {
+			return InternationalEmail{}, ErrInvalidEmailAddress
+		}
+		if unicodeDomain, err = idna.Lookup.ToUnicode(asciiDomain); err != nil {
+			return InternationalEmail{}, ErrInvalidEmailAddress
+		}
+	}
+
+	// The RFC 5322 parser accepts ASCII only, non-ASCII characters are allowed
+	// anywhere the ASCII letters are (RFC 6532, section 3.2), so validate a substitute.
+	smtpUTF8 := !isASCII(local)
+	if _, err := emailaddress.Parse(asciiSubstitute(local) + "@" + asciiDomain); err != nil {
+		return InternationalEmail{}, ErrInvalidEmailAddress
+	}
+
+	return InternationalEmail{
+		LocalPart:   local,
+		Domain:      unicodeDomain,
+		ASCIIDomain: asciiDomain,
+		SMTPUTF8:    smtpUTF8,
+	}, nil
+}
+
+// EmailToASCII converts the domain of the email address to ASCII form (punycode).
+func EmailToASCII(s string) (string, error) {
+	e, err := ParseInternationalEmail(s)
+	if err != nil {
+		return "", err
+	}
+	return e.ASCII(), nil
+}
+
+// EmailToUnicode converts the domain of the email address to Unicode form.
+func EmailToUnicode(s string) (string, error) {
+	e, err := ParseInternationalEmail(s)
+	if err != nil {
+		return "", err
+	}
+	return e.String(), nil
+}
+
+// RequiresSMTPUTF8 reports whether the email address contains a non-ASCII local part.
+// Such addresses can't be converted to ASCII and need SMTPUTF8 support to be delivered.
+func RequiresSMTPUTF8(s string) bool {
+	e, err := ParseInternationalEmail(s)
+	return err == nil && e.SMTPUTF8
+}
+
+// isASCII reports whether the string contains ASCII characters only.
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// asciiSubstitute replaces all non-ASCII characters with a letter.
+func asciiSubstitute(s string) string {
+	if isASCII(s) {
+		return s
+	}
+	return strings.Map(func(r rune) rune {
+		if r >= utf8.RuneSelf {
+			return 'a'
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,96 @@
+package utils_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dmitrymomot/go-utils"
+)
+
+func TestParseInternationalEmail(t *testing.T) {
+	tests := []struct {
+		name    string
+		email   string
+		want    utils.InternationalEmail
+		wantErr error
+	}{
+		{
+			name:  "ascii",
+			email: "John@Example.com",
+			want:  utils.InternationalEmail{LocalPart: "john", Domain: "example.com", ASCIIDomain: "example.com"},
+		},
+		{
+			name:  "idn domain",
+			email: "jose@bücher.de",
+			want:  utils.InternationalEmail{LocalPart: "jose", Domain: "bücher.de", ASCIIDomain: "xn--bcher-kva.de"},
+		},
+		{
+			name:  "punycode domain",
+			email: "jose@XN--BCHER-KVA.de",
+			want:  utils.InternationalEmail{LocalPart: "jose", Domain: "bücher.de", ASCIIDomain: "xn--bcher-kva.de"},
+		},
+		{
+			name:  "utf8 local part",
+			email: "用户@例子.广告",
+			want:  utils.InternationalEmail{LocalPart: "用户", Domain: "例子.广告", ASCIIDomain: "xn--fsqu00a.xn--4rr70v", SMTPUTF8: true},
+		},
+		{
+			name:  "nfc normalization",
+			email: "josé@bücher.de",
+			want:  utils.InternationalEmail{LocalPart: "josé", Domain: "bücher.de", ASCIIDomain: "xn--bcher-kva.de", SMTPUTF8: true},
+		},
+		{"missing at", "jose.bücher.de", utils.InternationalEmail{}, utils.ErrInvalidEmailAddress},
+		{"empty local part", "@bücher.de", utils.InternationalEmail{}, utils.ErrInvalidEmailAddress},
+		{"invalid domain", "jose@bü_cher.de", utils.InternationalEmail{}, utils.ErrInvalidEmailAddress},
+		{"invalid local part", "jo se@bücher.de", utils.InternationalEmail{}, utils.ErrInvalidEmailAddress},
+		{"invalid utf8", "jo\xffse@bücher.de", utils.InternationalEmail{}, utils.ErrInvalidEmailAddress},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := utils.ParseInternationalEmail(tt.email)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseInternationalEmail() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseInternationalEmail() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmailToASCII(t *testing.T) {
+	got, err := utils.EmailToASCII("josé@bücher.de")
+	if err != nil {
+		t.Fatalf("EmailToASCII() error = %v", err)
+	}
+	if got != "josé@xn--bcher-kva.de" {
+		t.Errorf("EmailToASCII() = %v, want %v", got, "josé@xn--bcher-kva.de")
+	}
+}
+
+func TestEmailToUnicode(t *testing.T) {
+	got, err := utils.EmailToUnicode("user@xn--fsqu00a.xn--4rr70v")
+	if err != nil {
+		t.Fatalf("EmailToUnicode() error = %v", err)
+	}
+	if got != "user@例子.广告" {
+		t.Errorf("EmailToUnicode() = %v, want %v", got, "user@例子.广告")
+	}
+}
+
+func TestRequiresSMTPUTF8(t *testing.T) {
+	tests := []struct {
+		email string
+		want  bool
+	}{
+		{"jose@bücher.de", false},
+		{"josé@bücher.de", true},
+		{"用户@例子.广告", true},
+		{"invalid", false},
+	}
+	for _, tt := range tests {
+		if got := utils.RequiresSMTPUTF8(tt.email); got != tt.want {
+			t.Errorf("RequiresSMTPUTF8(%q) = %v, want %v", tt.email, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"fmt"
+	"mime"
+	"net/mail"
+	"strings"
+)
+
+// EmailAddress is a single mailbox with an optional display name,
+// e.g. "Jane Doe <jane@example.com>".
+type EmailAddress struct {
+	// Name is the decoded display name, can be empty.
+	Name string
+	// Address is the addr-spec without quoting, e.g. "jane@example.com".
+	Address string
+}
+
+// String formats the address according to RFC 5322.
+// Non-ASCII display names are encoded according to RFC 2047.
+func (a EmailAddress) String() string {
+	return (&mail.Address{Name: a.Name, Address: a.Address}).String()
+}
+
+// Sanitize returns a copy of the address normalized with SanitizeEmail.
+// The display name is kept as is.
+func (a EmailAddress) Sanitize() (EmailAddress, error) {
+	addr, err := SanitizeEmail(emailAddrSpec(a.Address))
+	if addr == "" {
+		return a, err
+	}
+
+	// SanitizeEmail keeps quoting of the local part, the address is stored unquoted.
+	if parsed, perr := mail.ParseAddress(addr); perr == nil {
+		addr = parsed.Address
+	}
+
+	return EmailAddress{Name: a.Name, Address: addr}, err
+}
+
+// emailAddressParser decodes RFC 2047 encoded display names in any charset known to the mime package.
+var emailAddressParser = &mail.AddressParser{WordDecoder: &mime.WordDecoder{}}
+
+// ParseEmailAddress parses a single RFC 5322 address, e.g. "Jane Doe <jane@example.com>".
+func ParseEmailAddress(s string) (EmailAddress, error) {
+	if strings.TrimSpace(s) == "" {
+		return EmailAddress{}, ErrEmptyInput
+	}
+
+	addr, err := emailAddressParser.Parse(s)
+	if err != nil {
+		return EmailAddress{}, fmt.Errorf("%w: %s", ErrInvalidEmailAddress, err.Error())
+	}
+
+	return EmailAddress{Name: addr.Name, Address: addr.Address}, nil
+}
+
+// ParseEmailAddressList parses a comma separated list of RFC 5322 addresses,
+// e.g. a "To" header value: "Jane Doe <jane@x.com>, bob@y.com (Bob)".
+// Display names, quoted local parts, comments and RFC 2047 encoded words are supported.
+func ParseEmailAddressList(s string) ([]EmailAddress, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, ErrEmptyInput
+	}
+
+	list, err := emailAddressParser.ParseList(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidEmailAddress, err.Error())
+	}
+
+	result := make([]EmailAddress, 0, len(list))
+	for _, addr := range list {
+		result = append(result, EmailAddress{Name: addr.Name, Address: addr.Address})
+	}
+
+	return result, nil
+}
+
+// FormatEmailAddressList formats the addresses as a comma separated RFC 5322 address list.
+func FormatEmailAddressList(list []EmailAddress) string {
+	parts := make([]string, 0, len(list))
+	for _, a := range list {
+		parts = append(parts, a.String())
+	}
+	return strings.Join(parts, ", ")
+}
+
+// emailAddrSpec returns the address with the local part quoted if needed.
+func emailAddrSpec(addr string) string {
+	return strings.TrimSuffix(strings.TrimPrefix((&mail.Address{Address: addr}).String(), "<"), ">")
+}
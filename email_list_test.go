@@ -0,0 +1,135 @@
+package utils_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/dmitrymomot/go-utils"
+)
+
+func TestParseEmailAddressList(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []utils.EmailAddress
+		wantErr error
+	}{
+		{
+			name:  "display names",
+			input: `Jane Doe <jane@x.com>, bob@y.com`,
+			want: []utils.EmailAddress{
+				{Name: "Jane Doe", Address: "jane@x.com"},
+				{Address: "bob@y.com"},
+			},
+		},
+		{
+			name:  "quoted name and comment",
+			input: `"Doe, Jane" <jane@x.com>, bob@y.com (Bob)`,
+			want: []utils.EmailAddress{
+				{Name: "Doe, Jane", Address: "jane@x.com"},
+				{Name: "Bob", Address: "bob@y.com"},
+			},
+		},
+		{
+			name:  "quoted local part",
+			input: `"odd local"@x.com`,
+			want:  []utils.EmailAddress{{Address: "odd local@x.com"}},
+		},
+		{
+			name:  "encoded name",
+			input: `=?utf-8?q?J=C3=B6rg?= <jorg@z.de>, =?iso-8859-1?q?Ren=E9?= <rene@z.fr>`,
+			want: []utils.EmailAddress{
+				{Name: "Jörg", Address: "jorg@z.de"},
+				{Name: "René", Address: "rene@z.fr"},
+			},
+		},
+		{"empty", " ", nil, utils.ErrEmptyInput},
+		{"invalid", "Jane <jane.x.com>", nil, utils.ErrInvalidEmailAddress},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := utils.ParseEmailAddressList(tt.input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseEmailAddressList() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseEmailAddressList() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseEmailAddress(t *testing.T) {
+	got, err := utils.ParseEmailAddress("Jane Doe <jane@x.com>")
+	if err != nil {
+		t.Fatalf("ParseEmailAddress() error = %v", err)
+	}
+	if got.Name != "Jane Doe" || got.Address != "jane@x.com" {
+		t.Errorf("ParseEmailAddress() = %+v", got)
+	}
+
+	if _, err := utils.ParseEmailAddress("a@x.com, b@y.com"); !errors.Is(err, utils.ErrInvalidEmailAddress) {
+		t.Errorf("ParseEmailAddress() error = %v, want %v", err, utils.ErrInvalidEmailAddress)
+	}
+}
+
+func TestFormatEmailAddressList(t *testing.T) {
+	list := []utils.EmailAddress{
+		{Name: "Jane Doe", Address: "jane@x.com"},
+		{Name: "Doe, John", Address: "john@x.com"},
+		{Name: "Jörg", Address: "jorg@z.de"},
+		{Address: "odd local@x.com"},
+	}
+	want := `"Jane Doe" <jane@x.com>, "Doe, John" <john@x.com>, =?utf-8?q?J=C3=B6rg?= <jorg@z.de>, <"odd local"@x.com>`
+
+	got := utils.FormatEmailAddressList(list)
+	if got != want {
+		t.Fatalf("FormatEmailAddressList() = %v, want %v", got, want)
+	}
+
+	parsed, err := utils.ParseEmailAddressList(got)
+	if err != nil {
+		t.Fatalf("ParseEmailAddressList() error = %v", err)
+	}
+	if !reflect.DeepEqual(parsed, list) {
+		t.Errorf("round trip = %+v, want %+v", parsed, list)
+	}
+}
+
+func TestEmailAddress_Sanitize(t *testing.T) {
+	tests := []struct {
+		name    string
+		addr    utils.EmailAddress
+		want    utils.EmailAddress
+		wantErr error
+	}{
+		{
+			name: "provider rule",
+			addr: utils.EmailAddress{Name: "Jane", Address: "Ja.ne+news@GMail.com"},
+			want: utils.EmailAddress{Name: "Jane", Address: "jane@gmail.com"},
+		},
+		{
+			name: "quoted local part",
+			addr: utils.EmailAddress{Address: "John..Doe@x.com"},
+			want: utils.EmailAddress{Address: "john..doe@x.com"},
+		},
+		{
+			name:    "invalid",
+			addr:    utils.EmailAddress{Address: "jane"},
+			want:    utils.EmailAddress{Address: "jane"},
+			wantErr: utils.ErrInvalidEmailAddress,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.addr.Sanitize()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Sanitize() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Sanitize() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
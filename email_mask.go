@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// ErrEmptyHashKey is returned when the email hash key is empty.
+var ErrEmptyHashKey = errors.New("hash key cannot be empty")
+
+type (
+	// EmailMaskOption configures MaskEmail.
+	EmailMaskOption func(*emailMaskConfig)
+
+	emailMaskConfig struct {
+		char       rune
+		visible    int
+		length     int
+		maskDomain bool
+	}
+)
+
+// WithEmailMaskChar sets the mask character. Defaults to '*'.
+func WithEmailMaskChar(c rune) EmailMaskOption {
+	return func(cfg *emailMaskConfig) {
+		cfg.char = c
+	}
+}
+
+// WithEmailMaskVisibleChars sets how many characters are kept at both ends of the local part.
+// Defaults to 1.
+func WithEmailMaskVisibleChars(n int) EmailMaskOption {
+	return func(cfg *emailMaskConfig) {
+		if n >= 0 {
+			cfg.visible = n
+		}
+	}
+}
+
+// WithEmailMaskLength sets the fixed number of mask characters, so the length of the
+// original value isn't revealed. Zero keeps the original length. Defaults to 3.
+func WithEmailMaskLength(n int) EmailMaskOption {
+	return func(cfg *emailMaskConfig) {
+		if n >= 0 {
+			cfg.length = n
+		}
+	}
+}
+
+// WithEmailMaskDomain sets whether the domain name is masked, the public suffix is always kept.
+// Defaults to true.
+func WithEmailMaskDomain(mask bool) EmailMaskOption {
+	return func(cfg *emailMaskConfig) {
+		cfg.maskDomain = mask
+	}
+}
+
+// MaskEmail masks the email address for display and logs,
+// e.g. "john.doe@gmail.com" -> "j***e@g***.com".
+// A value which is not an email address is masked entirely.
+func MaskEmail(email string, opts ...EmailMaskOption) string {
+	cfg := &emailMaskConfig{char: '*', visible: 1, length: 3, maskDomain: true}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	email = strings.TrimSpace(email)
+	i := strings.LastIndex(email, "@")
+	if i <= 0 || i == len(email)-1 {
+		return cfg.mask(email, 0, 0)
+	}
+
+	local, domain := email[:i], email[i+1:]
+
+	// Never reveal the whole local part: at least one character is always masked.
+	visible := cfg.visible
+	if n := utf8.RuneCountInString(local); 2*visible >= n {
+		visible = (n - 1) / 2
+	}
+	local = cfg.mask(local, visible, visible)
+
+	if cfg.maskDomain {
+		// The suffix is lowercase, the domain keeps its case.
+		suffix, _ := publicsuffix.PublicSuffix(strings.ToLower(domain))
+		if n := len(domain) - len(suffix) - 1; n > 0 && domain[n] == '.' && strings.EqualFold(domain[n+1:], suffix) {
+			domain = cfg.mask(domain[:n], 1, 0) + domain[n:]
+		}
+	}
+
+	return local + "@" + domain
+}
+
+// mask replaces all but head leading and tail trailing characters of s with the mask character.
+func (cfg *emailMaskConfig) mask(s string, head, tail int) string {
+	runes := []rune(s)
+	if head+tail > len(runes) {
+		head, tail = 0, 0
+	}
+
+	n := cfg.length
+	if n == 0 {
+		n = len(runes) - head - tail
+	}
+
+	return string(runes[:head]) + strings.Repeat(string(cfg.char), n) + string(runes[len(runes)-tail:])
+}
+
+// HashEmail returns a hex encoded HMAC-SHA256 of the email address normalized with SanitizeEmail.
+// Equivalent addresses produce the same hash, so it can be stored and used
+// for lookups and deduplication instead of the plaintext address.
+func HashEmail(key []byte, email string) (string, error) {
+	if len(key) == 0 {
+		return "", ErrEmptyHashKey
+	}
+
+	normalized, err := SanitizeEmail(email)
+	if err != nil && !errors.Is(err, ErrInvalidIcanSuffix) {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(normalized))
+
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
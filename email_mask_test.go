@@ -0,0 +1,68 @@
+package utils_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dmitrymomot/go-utils"
+)
+
+func TestMaskEmail(t *testing.T) {
+	tests := []struct {
+		name  string
+		email string
+		opts  []utils.EmailMaskOption
+		want  string
+	}{
+		{"default", "john.doe@gmail.com", nil, "j***e@g***.com"},
+		{"multi label suffix", "john@yahoo.co.uk", nil, "j***n@y***.co.uk"},
+		{"short local part", "jo@x.com", nil, "***@x***.com"},
+		{"single char local part", "j@x.com", nil, "***@x***.com"},
+		{"unicode", "josé@bücher.de", nil, "j***é@b***.de"},
+		{"uppercase domain", "John.Doe@GMAIL.COM", nil, "J***e@G***.COM"},
+		{"mixed case suffix", "john@Yahoo.Co.UK", nil, "j***n@Y***.Co.UK"},
+		{"keep domain", "john.doe@gmail.com", []utils.EmailMaskOption{utils.WithEmailMaskDomain(false)}, "j***e@gmail.com"},
+		{"custom char", "john.doe@gmail.com", []utils.EmailMaskOption{utils.WithEmailMaskChar('#')}, "j###e@g###.com"},
+		{"visible chars", "john.doe@gmail.com", []utils.EmailMaskOption{utils.WithEmailMaskVisibleChars(2)}, "jo***oe@g***.com"},
+		{"original length", "john.doe@gmail.com", []utils.EmailMaskOption{utils.WithEmailMaskLength(0)}, "j******e@g****.com"},
+		{"not an email", "john.doe", nil, "***"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := utils.MaskEmail(tt.email, tt.opts...); got != tt.want {
+				t.Errorf("MaskEmail() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHashEmail(t *testing.T) {
+	key := []byte("secret")
+
+	h1, err := utils.HashEmail(key, "John.Doe+news@gmail.com")
+	if err != nil {
+		t.Fatalf("HashEmail() error = %v", err)
+	}
+	h2, err := utils.HashEmail(key, " johndoe@googlemail.com ")
+	if err != nil {
+		t.Fatalf("HashEmail() error = %v", err)
+	}
+	if h1 != h2 {
+		t.Errorf("expected equal hashes for equivalent addresses: %s != %s", h1, h2)
+	}
+	if len(h1) != 64 {
+		t.Errorf("expected hex encoded SHA-256, got %q", h1)
+	}
+
+	h3, _ := utils.HashEmail([]byte("other"), "johndoe@gmail.com")
+	if h1 == h3 {
+		t.Error("expected different hashes for different keys")
+	}
+
+	if _, err := utils.HashEmail(nil, "johndoe@gmail.com"); !errors.Is(err, utils.ErrEmptyHashKey) {
+		t.Errorf("HashEmail() error = %v, want %v", err, utils.ErrEmptyHashKey)
+	}
+	if _, err := utils.HashEmail(key, "johndoe"); !errors.Is(err, utils.ErrInvalidEmailAddress) {
+		t.Errorf("HashEmail() error = %v, want %v", err, utils.ErrInvalidEmailAddress)
+	}
+}
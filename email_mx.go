@@ -0,0 +1,268 @@
+package utils
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/idna"
+)
+
+// Predefined mail server errors
+var (
+	ErrNullMX       = errors.New("domain does not accept email (null MX)")
+	ErrNoMailServer = errors.New("domain has no mail server")
+)
+
+// MXResolver is the subset of *net.Resolver used to look up mail servers.
+// It can be replaced with a fake implementation in tests.
+type MXResolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// Default settings of the email domain verifier.
+const (
+	DefaultMXLookupTimeout = 5 * time.Second
+	DefaultMXCacheTTL      = 10 * time.Minute
+	DefaultMXCacheSize     = 10000
+)
+
+// defaultEmailDomainVerifier is used by the package level helpers.
+var defaultEmailDomainVerifier = NewEmailDomainVerifier()
+
+type (
+	// EmailDomainVerifier checks whether an email domain can receive mail.
+	// Results are cached, it's safe for concurrent use.
+	EmailDomainVerifier struct {
+		resolver MXResolver
+		timeout  time.Duration
+		ttl      time.Duration
+		size     int
+		now      func() time.Time
+
+		mu    sync.Mutex
+		cache map[string]*list.Element
+		// order keeps the cached entries from the oldest to the newest,
+		// all entries have the same TTL, so they expire in this order too.
+		order *list.List
+	}
+
+	// EmailDomainVerifierOption configures the email domain verifier.
+	EmailDomainVerifierOption func(*EmailDomainVerifier)
+
+	mxCacheEntry struct {
+		domain  string
+		hosts   []string
+		err     error
+		expires time.Time
+	}
+)
+
+// WithMXResolver sets the DNS resolver. Defaults to net.DefaultResolver.
+func WithMXResolver(r MXResolver) EmailDomainVerifierOption {
+	return func(v *EmailDomainVerifier) {
+		if r != nil {
+			v.resolver = r
+		}
+	}
+}
+
+// WithMXLookupTimeout sets the timeout of a single domain verification.
+// Zero or negative value disables the timeout, the context deadline is used only.
+func WithMXLookupTimeout(d time.Duration) EmailDomainVerifierOption {
+	return func(v *EmailDomainVerifier) {
+		v.timeout = d
+	}
+}
+
+// WithMXCacheTTL sets how long verification results are cached.
+// Zero or negative value disables caching.
+func WithMXCacheTTL(d time.Duration) EmailDomainVerifierOption {
+	return func(v *EmailDomainVerifier) {
+		v.ttl = d
+	}
+}
+
+// WithMXCacheSize sets the max number of cached domains, the oldest entries are evicted first.
+// Defaults to DefaultMXCacheSize, zero or negative value disables the limit.
+func WithMXCacheSize(n int) EmailDomainVerifierOption {
+	return func(v *EmailDomainVerifier) {
+		v.size = n
+	}
+}
+
+// NewEmailDomainVerifier returns a new email domain verifier.
+func NewEmailDomainVerifier(opts ...EmailDomainVerifierOption) *EmailDomainVerifier {
+	v := &EmailDomainVerifier{
+		resolver: net.DefaultResolver,
+		timeout:  DefaultMXLookupTimeout,
+		ttl:      DefaultMXCacheTTL,
+		size:     DefaultMXCacheSize,
+		now:      time.Now,
+		cache:    make(map[string]*list.Element),
+		order:    list.New(),
+	}
+
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	return v
+}
+
+// VerifyDomain returns the mail hosts of the domain ordered by preference.
+// It looks up MX records and falls back to the domain itself if it has A/AAAA records (RFC 5321, section 5.1).
+// ErrNullMX is returned if the domain explicitly doesn't accept email (RFC 7505),
+// ErrNoMailServer if there are neither MX nor address records.
+func (v *EmailDomainVerifier) VerifyDomain(ctx context.Context, domain string) ([]string, error) {
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+	if domain == "" {
+		return nil, ErrEmptyInput
+	}
+	if d, err := idna.Lookup.ToASCII(domain); err == nil {
+		domain = d
+	}
+
+	// The cached hosts are copied, so callers can't modify them.
+	if entry, ok := v.fromCache(domain); ok {
+		return append([]string(nil), entry.hosts...), entry.err
+	}
+
+	if v.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, v.timeout)
+		defer cancel()
+	}
+
+	hosts, err := v.lookup(ctx, domain)
+	if err == nil || errors.Is(err, ErrNullMX) || errors.Is(err, ErrNoMailServer) {
+		v.toCache(domain, append([]string(nil), hosts...), err)
+	}
+
+	return hosts, err
+}
+
+// VerifyEmail checks whether the domain of the email address can receive mail.
+func (v *EmailDomainVerifier) VerifyEmail(ctx context.Context, email string) error {
+	e, err := ParseInternationalEmail(email)
+	if err != nil {
+		return ErrInvalidEmailAddress
+	}
+
+	_, err = v.VerifyDomain(ctx, e.ASCIIDomain)
+	return err
+}
+
+// lookup resolves mail hosts of the domain.
+func (v *EmailDomainVerifier) lookup(ctx context.Context, domain string) ([]string, error) {
+	records, err := v.resolver.LookupMX(ctx, domain)
+	if err != nil && !isDNSNotFound(err) {
+		return nil, fmt.Errorf("failed to lookup MX records: %w", err)
+	}
+
+	if len(records) > 0 {
+		// Null MX: a single record with an empty target.
+		if len(records) == 1 && (records[0].Host == "." || records[0].Host == "") {
+			return nil, ErrNullMX
+		}
+
+		sort.SliceStable(records, func(i, j int) bool {
+			return records[i].Pref < records[j].Pref
+		})
+
+		hosts := make([]string, 0, len(records))
+		for _, r := range records {
+			if h := strings.TrimSuffix(r.Host, "."); h != "" {
+				hosts = append(hosts, h)
+			}
+		}
+
+		return hosts, nil
+	}
+
+	// No MX records: the domain itself is the implicit mail host.
+	addrs, err := v.resolver.LookupIPAddr(ctx, domain)
+	if err != nil && !isDNSNotFound(err) {
+		return nil, fmt.Errorf("failed to lookup address records: %w", err)
+	}
+	if len(addrs) == 0 {
+		return nil, ErrNoMailServer
+	}
+
+	return []string{domain}, nil
+}
+
+func (v *EmailDomainVerifier) fromCache(domain string) (mxCacheEntry, bool) {
+	if v.ttl <= 0 {
+		return mxCacheEntry{}, false
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	el, ok := v.cache[domain]
+	if !ok {
+		return mxCacheEntry{}, false
+	}
+	entry := el.Value.(mxCacheEntry)
+	if v.now().After(entry.expires) {
+		v.removeFromCache(el)
+		return mxCacheEntry{}, false
+	}
+
+	return entry, true
+}
+
+func (v *EmailDomainVerifier) toCache(domain string, hosts []string, err error) {
+	if v.ttl <= 0 {
+		return
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	now := v.now()
+	if el, ok := v.cache[domain]; ok {
+		v.removeFromCache(el)
+	}
+
+	// Expired entries are swept on insert, so domains looked up only once don't pile up.
+	for el := v.order.Front(); el != nil; el = v.order.Front() {
+		if !now.After(el.Value.(mxCacheEntry).expires) && (v.size <= 0 || v.order.Len() < v.size) {
+			break
+		}
+		v.removeFromCache(el)
+	}
+
+	v.cache[domain] = v.order.PushBack(mxCacheEntry{
+		domain:  domain,
+		hosts:   hosts,
+		err:     err,
+		expires: now.Add(v.ttl),
+	})
+}
+
+// removeFromCache removes the cache entry, the caller must hold the lock.
+func (v *EmailDomainVerifier) removeFromCache(el *list.Element) {
+	v.order.Remove(el)
+	delete(v.cache, el.Value.(mxCacheEntry).domain)
+}
+
+// isDNSNotFound reports whether the error means that the requested records don't exist.
+func isDNSNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
+
+// VerifyEmailDomain checks whether the domain of the email address can receive mail
+// using the system DNS resolver.
+func VerifyEmailDomain(ctx context.Context, email string) error {
+	return defaultEmailDomainVerifier.VerifyEmail(ctx, email)
+}
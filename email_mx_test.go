@@ -0,0 +1,191 @@
+package utils_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dmitrymomot/go-utils"
+)
+
+// fakeResolver is an in-memory MXResolver
+type fakeResolver struct {
+	mu    sync.Mutex
+	mx    map[string][]*net.MX
+	ip    map[string][]net.IPAddr
+	err   error
+	calls int
+	delay time.Duration
+}
+
+func (r *fakeResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	r.mu.Lock()
+	r.calls++
+	r.mu.Unlock()
+
+	if r.delay > 0 {
+		select {
+		case <-time.After(r.delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	if records, ok := r.mx[name]; ok {
+		return records, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+func (r *fakeResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	if addrs, ok := r.ip[host]; ok {
+		return addrs, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func newFakeResolver() *fakeResolver {
+	return &fakeResolver{
+		mx: map[string][]*net.MX{
+			"example.com": {
+				{Host: "mx2.example.com.", Pref: 20},
+				{Host: "mx1.example.com.", Pref: 10},
+			},
+			"nullmx.com": {{Host: ".", Pref: 0}},
+		},
+		ip: map[string][]net.IPAddr{
+			"a-only.com": {{IP: net.ParseIP("192.0.2.1")}},
+		},
+	}
+}
+
+func TestEmailDomainVerifier_VerifyDomain(t *testing.T) {
+	v := utils.NewEmailDomainVerifier(utils.WithMXResolver(newFakeResolver()))
+
+	tests := []struct {
+		name    string
+		domain  string
+		want    []string
+		wantErr error
+	}{
+		{"mx records", "Example.com.", []string{"mx1.example.com", "mx2.example.com"}, nil},
+		{"implicit mx", "a-only.com", []string{"a-only.com"}, nil},
+		{"null mx", "nullmx.com", nil, utils.ErrNullMX},
+		{"no mail server", "nowhere.com", nil, utils.ErrNoMailServer},
+		{"empty domain", "", nil, utils.ErrEmptyInput},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := v.VerifyDomain(context.Background(), tt.domain)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("VerifyDomain() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("VerifyDomain() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmailDomainVerifier_VerifyEmail(t *testing.T) {
+	v := utils.NewEmailDomainVerifier(utils.WithMXResolver(newFakeResolver()))
+
+	if err := v.VerifyEmail(context.Background(), "john@example.com"); err != nil {
+		t.Errorf("VerifyEmail() error = %v", err)
+	}
+	if err := v.VerifyEmail(context.Background(), "john@nullmx.com"); !errors.Is(err, utils.ErrNullMX) {
+		t.Errorf("VerifyEmail() error = %v, want %v", err, utils.ErrNullMX)
+	}
+	if err := v.VerifyEmail(context.Background(), "john.example.com"); !errors.Is(err, utils.ErrInvalidEmailAddress) {
+		t.Errorf("VerifyEmail() error = %v, want %v", err, utils.ErrInvalidEmailAddress)
+	}
+}
+
+func TestEmailDomainVerifier_Cache(t *testing.T) {
+	t.Run("cached result", func(t *testing.T) {
+		r := newFakeResolver()
+		v := utils.NewEmailDomainVerifier(utils.WithMXResolver(r))
+
+		var want string
+		for i := 0; i < 3; i++ {
+			hosts, err := v.VerifyDomain(context.Background(), "example.com")
+			if err != nil || len(hosts) == 0 {
+				t.Fatalf("VerifyDomain() = %v, %v", hosts, err)
+			}
+			if i == 0 {
+				want = hosts[0]
+			}
+			if hosts[0] != want {
+				t.Errorf("cached hosts were modified: %v", hosts)
+			}
+			// Modifying the result must not affect the cache.
+			hosts[0] = "modified.example.com"
+		}
+		if r.calls != 1 {
+			t.Errorf("expected 1 lookup, got %d", r.calls)
+		}
+	})
+
+	t.Run("cache disabled", func(t *testing.T) {
+		r := newFakeResolver()
+		v := utils.NewEmailDomainVerifier(utils.WithMXResolver(r), utils.WithMXCacheTTL(0))
+
+		for i := 0; i < 3; i++ {
+			if _, err := v.VerifyDomain(context.Background(), "example.com"); err != nil {
+				t.Fatalf("VerifyDomain() error = %v", err)
+			}
+		}
+		if r.calls != 3 {
+			t.Errorf("expected 3 lookups, got %d", r.calls)
+		}
+	})
+
+	t.Run("cache size", func(t *testing.T) {
+		r := newFakeResolver()
+		v := utils.NewEmailDomainVerifier(utils.WithMXResolver(r), utils.WithMXCacheSize(2))
+
+		for _, domain := range []string{"a.com", "b.com", "c.com", "c.com", "b.com"} {
+			_, _ = v.VerifyDomain(context.Background(), domain)
+		}
+		if r.calls != 3 {
+			t.Errorf("expected 3 lookups, got %d", r.calls)
+		}
+
+		// The oldest entry is evicted.
+		_, _ = v.VerifyDomain(context.Background(), "a.com")
+		if r.calls != 4 {
+			t.Errorf("expected 4 lookups, got %d", r.calls)
+		}
+	})
+
+	t.Run("temporary errors are not cached", func(t *testing.T) {
+		r := newFakeResolver()
+		r.err = errors.New("server misbehaving")
+		v := utils.NewEmailDomainVerifier(utils.WithMXResolver(r))
+
+		if _, err := v.VerifyDomain(context.Background(), "example.com"); err == nil {
+			t.Fatal("expected error but got none")
+		}
+		r.err = nil
+		if _, err := v.VerifyDomain(context.Background(), "example.com"); err != nil {
+			t.Errorf("VerifyDomain() error = %v", err)
+		}
+	})
+}
+
+func TestEmailDomainVerifier_Timeout(t *testing.T) {
+	r := newFakeResolver()
+	r.delay = time.Second
+	v := utils.NewEmailDomainVerifier(utils.WithMXResolver(r), utils.WithMXLookupTimeout(10*time.Millisecond))
+
+	_, err := v.VerifyDomain(context.Background(), "example.com")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("VerifyDomain() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
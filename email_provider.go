@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"strings"
+	"sync"
+)
+
+// EmailProviderRule describes how the local part and domain of an email address
+// are normalized for a specific mail provider.
+type EmailProviderRule struct {
+	// StripDots removes all dots from the local part (e.g. Gmail ignores them).
+	StripDots bool
+	// StripPlusTag removes the "+tag" suffix from the local part.
+	StripPlusTag bool
+	// StripDashTag removes the "-keyword" suffix from the local part (e.g. Yahoo disposable addresses).
+	StripDashTag bool
+	// SubdomainAlias maps "anything@user.domain" to "user@domain" (e.g. Fastmail).
+	SubdomainAlias bool
+	// CanonicalDomain replaces the domain with the given one if not empty (e.g. googlemail.com -> gmail.com).
+	CanonicalDomain string
+}
+
+// DefaultEmailProviderRule is used for domains without a registered rule.
+// It keeps the local part as is, the address is only lowercased.
+var DefaultEmailProviderRule = EmailProviderRule{}
+
+// Predefined email provider rules
+var (
+	emailProviderRulesMu sync.RWMutex
+	emailProviderRules   = map[string]EmailProviderRule{
+		"gmail.com":      {StripDots: true, StripPlusTag: true},
+		"googlemail.com": {StripDots: true, StripPlusTag: true, CanonicalDomain: "gmail.com"},
+		"outlook.com":    {StripPlusTag: true},
+		"hotmail.com":    {StripPlusTag: true},
+		"live.com":       {StripPlusTag: true},
+		"yahoo.com":      {StripDashTag: true},
+		"ymail.com":      {StripDashTag: true},
+		"rocketmail.com": {StripDashTag: true},
+		"fastmail.com":   {StripPlusTag: true, SubdomainAlias: true},
+		"fastmail.fm":    {StripPlusTag: true, SubdomainAlias: true},
+	}
+)
+
+// RegisterEmailProviderRule registers a normalization rule for the given domain.
+// If a rule for the domain already exists, it will be replaced.
+// Registering DefaultEmailProviderRule removes the rule of the domain.
+func RegisterEmailProviderRule(domain string, rule EmailProviderRule) {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	if domain == "" {
+		return
+	}
+
+	emailProviderRulesMu.Lock()
+	defer emailProviderRulesMu.Unlock()
+
+	if rule == DefaultEmailProviderRule {
+		delete(emailProviderRules, domain)
+		return
+	}
+	emailProviderRules[domain] = rule
+}
+
+// GetEmailProviderRule returns the normalization rule for the given domain.
+// If there is no rule registered for the domain, DefaultEmailProviderRule is returned.
+func GetEmailProviderRule(domain string) EmailProviderRule {
+	rule, _ := lookupEmailProviderRule(strings.ToLower(domain))
+	return rule
+}
+
+// lookupEmailProviderRule returns the rule for the exact domain or,
+// if the parent domain has a subdomain alias rule, the rule of the parent domain.
+func lookupEmailProviderRule(domain string) (EmailProviderRule, string) {
+	emailProviderRulesMu.RLock()
+	defer emailProviderRulesMu.RUnlock()
+
+	if rule, ok := emailProviderRules[domain]; ok {
+		return rule, domain
+	}
+
+	if i := strings.Index(domain, "."); i > 0 {
+		parent := domain[i+1:]
+		if rule, ok := emailProviderRules[parent]; ok && rule.SubdomainAlias {
+			return rule, parent
+		}
+	}
+
+	return DefaultEmailProviderRule, domain
+}
+
+// normalizeEmailParts applies the provider rule to the lowercased local part and domain.
+func normalizeEmailParts(username, domain string) (string, string) {
+	rule, ruleDomain := lookupEmailProviderRule(domain)
+	original := username
+
+	if ruleDomain != domain {
+		// Subdomain alias: the subdomain is the mailbox name.
+		username = strings.TrimSuffix(domain, "."+ruleDomain)
+		domain = ruleDomain
+	}
+
+	if rule.StripPlusTag {
+		username = strings.SplitN(username, "+", 2)[0]
+	}
+
+	if rule.StripDashTag {
+		username = strings.SplitN(username, "-", 2)[0]
+	}
+
+	if rule.StripDots {
+		username = strings.ReplaceAll(username, ".", "")
+	}
+
+	// Never produce an empty mailbox name, e.g. for "+tag@gmail.com".
+	if username == "" {
+		username = original
+	}
+
+	if rule.CanonicalDomain != "" {
+		domain = rule.CanonicalDomain
+	}
+
+	return username, domain
+}
@@ -0,0 +1,50 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/dmitrymomot/go-utils"
+)
+
+func TestRegisterEmailProviderRule(t *testing.T) {
+	t.Run("default rule", func(t *testing.T) {
+		rule := utils.GetEmailProviderRule("example.org")
+		if rule != utils.DefaultEmailProviderRule {
+			t.Errorf("GetEmailProviderRule() = %+v, want default rule", rule)
+		}
+	})
+
+	t.Run("custom rule", func(t *testing.T) {
+		utils.RegisterEmailProviderRule("Corp.Example.com", utils.EmailProviderRule{
+			StripPlusTag:    true,
+			CanonicalDomain: "example.com",
+		})
+		t.Cleanup(func() {
+			utils.RegisterEmailProviderRule("corp.example.com", utils.DefaultEmailProviderRule)
+		})
+
+		got, err := utils.SanitizeEmail("Jo.hn+news@corp.example.com")
+		if err != nil {
+			t.Fatalf("SanitizeEmail() error = %v", err)
+		}
+		if got != "jo.hn@example.com" {
+			t.Errorf("SanitizeEmail() = %v, want %v", got, "jo.hn@example.com")
+		}
+	})
+
+	t.Run("remove rule", func(t *testing.T) {
+		utils.RegisterEmailProviderRule("tmp.example.com", utils.EmailProviderRule{StripPlusTag: true})
+		utils.RegisterEmailProviderRule("tmp.example.com", utils.DefaultEmailProviderRule)
+
+		if rule := utils.GetEmailProviderRule("tmp.example.com"); rule != utils.DefaultEmailProviderRule {
+			t.Errorf("GetEmailProviderRule() = %+v, want default rule", rule)
+		}
+	})
+
+	t.Run("subdomain without alias rule", func(t *testing.T) {
+		rule := utils.GetEmailProviderRule("mail.gmail.com")
+		if rule != utils.DefaultEmailProviderRule {
+			t.Errorf("GetEmailProviderRule() = %+v, want default rule", rule)
+		}
+	})
+}
@@ -0,0 +1,325 @@
+package utils
+
+import (
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// DefaultPopularEmailDomains is the default list of domains used to suggest typo corrections.
+var DefaultPopularEmailDomains = []string{
+	"aol.com", "att.net", "comcast.net", "facebook.com", "fastmail.com",
+	"gmail.com", "gmx.com", "gmx.de", "googlemail.com", "hotmail.co.uk",
+	"hotmail.com", "hotmail.fr", "icloud.com", "live.com", "mac.com",
+	"mail.com", "mail.ru", "me.com", "msn.com", "outlook.com",
+	"protonmail.com", "qq.com", "web.de", "yahoo.co.uk", "yahoo.com",
+	"yahoo.fr", "yandex.ru", "ymail.com", "zoho.com",
+}
+
+// DefaultPopularTLDs is the default list of top level domains used to suggest typo corrections.
+var DefaultPopularTLDs = []string{
+	"com", "net", "org", "info", "edu", "gov", "io", "co", "co.uk",
+	"de", "fr", "ru", "uk", "us", "ca", "au", "it", "es", "nl", "jp",
+}
+
+// Default settings of the email suggester.
+const (
+	// DefaultEmailSuggestMaxDistance is the default max edit distance between a domain name and a suggestion.
+	// Names shorter than emailSuggestShortName runes allow a single edit only.
+	DefaultEmailSuggestMaxDistance = 2
+	// DefaultEmailSuggestMinConfidence is the default min confidence of a suggestion.
+	DefaultEmailSuggestMinConfidence = 0.75
+
+	emailSuggestShortName = 6
+)
+
+// defaultEmailSuggester is used by the package level helpers.
+var defaultEmailSuggester = NewEmailSuggester()
+
+type (
+	// EmailSuggestion is a suggested correction of a mistyped email address.
+	EmailSuggestion struct {
+		// Address is the corrected email address.
+		Address string
+		// Domain is the corrected domain.
+		Domain string
+		// Confidence is a score from 0 to 1, higher is more certain.
+		Confidence float64
+	}
+
+	// EmailSuggester suggests corrections for mistyped email domains, e.g. "gmial.com" -> "gmail.com".
+	EmailSuggester struct {
+		domains       []string
+		tlds          []string
+		maxDistance   int
+		minConfidence float64
+	}
+
+	// EmailSuggesterOption configures the email suggester.
+	EmailSuggesterOption func(*EmailSuggester)
+)
+
+// WithPopularEmailDomains replaces the list of popular domains.
+func WithPopularEmailDomains(domains ...string) EmailSuggesterOption {
+	return func(s *EmailSuggester) {
+		s.domains = normalizeDomainList(domains)
+	}
+}
+
+// WithPopularTLDs replaces the list of popular top level domains.
+func WithPopularTLDs(tlds ...string) EmailSuggesterOption {
+	return func(s *EmailSuggester) {
+		s.tlds = normalizeDomainList(tlds)
+	}
+}
+
+// WithEmailSuggestMaxDistance sets the max edit distance between a domain and a suggestion.
+func WithEmailSuggestMaxDistance(d int) EmailSuggesterOption {
+	return func(s *EmailSuggester) {
+		if d > 0 {
+			s.maxDistance = d
+		}
+	}
+}
+
+// WithEmailSuggestMinConfidence sets the min confidence of a suggestion, from 0 to 1.
+func WithEmailSuggestMinConfidence(c float64) EmailSuggesterOption {
+	return func(s *EmailSuggester) {
+		if c >= 0 && c <= 1 {
+			s.minConfidence = c
+		}
+	}
+}
+
+// NewEmailSuggester returns a new email suggester.
+func NewEmailSuggester(opts ...EmailSuggesterOption) *EmailSuggester {
+	s := &EmailSuggester{
+		domains:       normalizeDomainList(DefaultPopularEmailDomains),
+		tlds:          normalizeDomainList(DefaultPopularTLDs),
+		maxDistance:   DefaultEmailSuggestMaxDistance,
+		minConfidence: DefaultEmailSuggestMinConfidence,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Suggest returns a corrected email address if the domain looks like a typo
+// of a popular domain or the top level domain looks like a typo of a popular TLD.
+// Real TLDs are never corrected, e.g. "company.dev" is not suggested as "company.de".
+// The second return value is false if there is nothing to suggest.
+func (s *EmailSuggester) Suggest(email string) (EmailSuggestion, bool) {
+	email = strings.TrimSpace(email)
+	i := strings.LastIndex(email, "@")
+	if i <= 0 || i == len(email)-1 {
+		return EmailSuggestion{}, false
+	}
+
+	local, domain := email[:i], strings.ToLower(email[i+1:])
+
+	// Known domain, nothing to correct.
+	for _, d := range s.domains {
+		if d == domain {
+			return EmailSuggestion{}, false
+		}
+	}
+
+	if d, confidence, ok := s.closestDomain(domain); ok {
+		return EmailSuggestion{
+			Address:    local + "@" + d,
+			Domain:     d,
+			Confidence: confidence,
+		}, true
+	}
+
+	// Try to fix the top level domain only, e.g. "company.con" -> "company.com".
+	name, tld := splitDomainTLD(domain, s.tlds)
+	if name == "" {
+		return EmailSuggestion{}, false
+	}
+	for _, t := range s.tlds {
+		if t == tld {
+			return EmailSuggestion{}, false
+		}
+	}
+	// Real TLDs missing from the list, e.g. "dev" or "ai", are not typos.
+	if _, icann := publicsuffix.PublicSuffix(domain); icann {
+		return EmailSuggestion{}, false
+	}
+	if t, dist, ok := closestMatch(tld, s.tlds, 1); ok {
+		d := name + "." + t
+		// The confidence is computed for the whole domain, like for the popular domains.
+		if confidence := typoConfidence(domain, d, dist); confidence >= s.minConfidence {
+			return EmailSuggestion{
+				Address:    local + "@" + d,
+				Domain:     d,
+				Confidence: confidence,
+			}, true
+		}
+	}
+
+	return EmailSuggestion{}, false
+}
+
+// SuggestEmail returns a corrected email address using the default popular domains.
+// The second return value is false if there is nothing to suggest.
+func SuggestEmail(email string) (EmailSuggestion, bool) {
+	return defaultEmailSuggester.Suggest(email)
+}
+
+// closestDomain returns the popular domain closest to the given one.
+// If the TLD of the domain is valid, only the names of popular domains with the same TLD are compared,
+// so real domains like "gmx.net" or "yahoo.de" are not corrected to other popular domains.
+func (s *EmailSuggester) closestDomain(domain string) (string, float64, bool) {
+	name, tld := splitDomainTLD(domain, s.tlds)
+	validTLD := false
+	for _, t := range s.tlds {
+		if name != "" && t == tld {
+			validTLD = true
+			break
+		}
+	}
+
+	best, bestConfidence := "", 0.0
+	for _, candidate := range s.domains {
+		candidateName, candidateTLD := splitDomainTLD(candidate, s.tlds)
+		if candidateName == "" {
+			candidateName = candidate
+		}
+
+		a, b := domain, candidate
+		if validTLD {
+			if candidateTLD != tld {
+				continue
+			}
+			a, b = name, candidateName
+		}
+
+		limit := s.maxDistance
+		if len([]rune(candidateName)) < emailSuggestShortName {
+			limit = minInt(limit, 1)
+		}
+
+		dist := editDistance(a, b)
+		if dist == 0 || dist > limit {
+			continue
+		}
+		if confidence := typoConfidence(a, b, dist); confidence > bestConfidence {
+			best, bestConfidence = candidate, confidence
+		}
+	}
+
+	if best == "" || bestConfidence < s.minConfidence {
+		return "", 0, false
+	}
+	return best, bestConfidence, true
+}
+
+// typoConfidence returns the similarity of the strings with the given edit distance, from 0 to 1.
+// People rarely mistype the first letter, so such matches get half the score,
+// e.g. "email" is not a typo of "gmail", unless the first two letters are swapped.
+func typoConfidence(a, b string, dist int) float64 {
+	ra, rb := []rune(a), []rune(b)
+	length := len(ra)
+	if len(rb) > length {
+		length = len(rb)
+	}
+
+	confidence := 1 - float64(dist)/float64(length)
+	if len(ra) > 0 && len(rb) > 0 && ra[0] != rb[0] {
+		swapped := len(ra) > 1 && len(rb) > 1 && ra[0] == rb[1] && ra[1] == rb[0]
+		if !swapped {
+			confidence /= 2
+		}
+	}
+
+	return confidence
+}
+
+// closestMatch returns the candidate with the smallest edit distance to s and the distance,
+// if the distance is not greater than limit.
+func closestMatch(s string, candidates []string, limit int) (string, int, bool) {
+	best, bestDist := "", limit+1
+	for _, c := range candidates {
+		if d := editDistance(s, c); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	if best == "" || bestDist == 0 {
+		return "", 0, false
+	}
+
+	return best, bestDist, true
+}
+
+// splitDomainTLD splits the domain into the name and the top level domain.
+// Multi-label TLDs from the list (e.g. "co.uk") are matched first.
+func splitDomainTLD(domain string, tlds []string) (string, string) {
+	for _, t := range tlds {
+		if strings.Contains(t, ".") && strings.HasSuffix(domain, "."+t) {
+			return strings.TrimSuffix(domain, "."+t), t
+		}
+	}
+
+	i := strings.LastIndex(domain, ".")
+	if i <= 0 {
+		return "", ""
+	}
+
+	return domain[:i], domain[i+1:]
+}
+
+// editDistance returns the optimal string alignment distance between a and b:
+// the number of insertions, deletions, substitutions and transpositions of adjacent characters.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			curr[j] = minInt(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				curr[j] = minInt(curr[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+
+	return prev[len(rb)]
+}
+
+// minInt returns the smallest of the given values.
+func minInt(v int, values ...int) int {
+	for _, x := range values {
+		if x < v {
+			v = x
+		}
+	}
+	return v
+}
+
+// normalizeDomainList lowercases and trims the domains, empty values are skipped.
+func normalizeDomainList(domains []string) []string {
+	result := make([]string, 0, len(domains))
+	for _, d := range domains {
+		if d = strings.Trim(strings.ToLower(strings.TrimSpace(d)), "."); d != "" {
+			result = append(result, d)
+		}
+	}
+	return result
+}
@@ -0,0 +1,91 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/dmitrymomot/go-utils"
+)
+
+func TestSuggestEmail(t *testing.T) {
+	tests := []struct {
+		name   string
+		email  string
+		want   string
+		wantOk bool
+	}{
+		{"transposition", "john@gmial.com", "john@gmail.com", true},
+		{"wrong tld", "john@hotmail.con", "john@hotmail.com", true},
+		{"missing letter", "john@yaho.com", "john@yahoo.com", true},
+		{"uppercase", "John@GMAI.COM", "John@gmail.com", true},
+		{"custom domain tld", "john@acme.cmo", "john@acme.com", true},
+		{"multi label tld", "john@acme.co.ukk", "john@acme.co.uk", true},
+		{"known domain", "john@gmail.com", "", false},
+		{"known tld", "john@acme-corp.io", "", false},
+		{"too far", "john@example.org", "", false},
+		{"invalid", "john", "", false},
+		{"swapped first letters", "john@mgail.com", "john@gmail.com", true},
+		{"long name", "john@hotmial.com", "john@hotmail.com", true},
+		{"two typos in long name", "john@protnmial.com", "john@protonmail.com", true},
+		// Real domains must not be corrected to a similar popular domain.
+		{"real short domain", "john@acme.com", "", false},
+		{"real two letter domain", "john@hp.com", "", false},
+		{"real domain similar to gmx", "john@gm.com", "", false},
+		{"popular name with other tld", "john@gmx.net", "", false},
+		{"yahoo with other tld", "john@yahoo.de", "", false},
+		{"mail with other tld", "john@mail.de", "", false},
+		{"different first letter", "john@email.com", "", false},
+		// Real TLDs missing from the popular list are not typos.
+		{"dev tld", "john@company.dev", "", false},
+		{"ai tld", "john@company.ai", "", false},
+		{"me tld", "john@company.me", "", false},
+		{"ch tld", "john@company.ch", "", false},
+		{"at tld", "john@company.at", "", false},
+		{"pl tld", "john@company.pl", "", false},
+		{"be tld", "john@company.be", "", false},
+		{"in tld", "john@company.in", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := utils.SuggestEmail(tt.email)
+			if ok != tt.wantOk {
+				t.Fatalf("SuggestEmail() ok = %v, want %v (%+v)", ok, tt.wantOk, got)
+			}
+			if got.Address != tt.want {
+				t.Errorf("SuggestEmail() = %v, want %v", got.Address, tt.want)
+			}
+			if ok && (got.Confidence <= 0 || got.Confidence >= 1) {
+				t.Errorf("SuggestEmail() confidence = %v, want (0, 1)", got.Confidence)
+			}
+		})
+	}
+}
+
+func TestEmailSuggester(t *testing.T) {
+	s := utils.NewEmailSuggester(
+		utils.WithPopularEmailDomains("acme.com", "Example.ORG"),
+		utils.WithPopularTLDs("com", "org"),
+		utils.WithEmailSuggestMaxDistance(1),
+		utils.WithEmailSuggestMinConfidence(0.5),
+	)
+
+	if got, ok := s.Suggest("jane@exmple.org"); !ok || got.Domain != "example.org" {
+		t.Errorf("Suggest() = %+v, %v, want example.org", got, ok)
+	}
+	if got, ok := s.Suggest("jane@gmial.com"); ok {
+		t.Errorf("Suggest() = %+v, want no suggestion for domains outside of the list", got)
+	}
+
+	strict := utils.NewEmailSuggester(utils.WithEmailSuggestMinConfidence(0.9))
+	if got, ok := strict.Suggest("jane@gmial.com"); ok {
+		t.Errorf("Suggest() = %+v, want no suggestion below the min confidence", got)
+	}
+	if got, ok := strict.Suggest("jane@acme.cmo"); ok {
+		t.Errorf("Suggest() = %+v, want no tld suggestion below the min confidence", got)
+	}
+
+	closer, _ := s.Suggest("jane@acme.cm")
+	further, _ := utils.NewEmailSuggester(utils.WithPopularEmailDomains("acme.com")).Suggest("jane@acm.cm")
+	if closer.Confidence <= further.Confidence {
+		t.Errorf("expected higher confidence for a closer match: %v <= %v", closer.Confidence, further.Confidence)
+	}
+}
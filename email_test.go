@@ -1,6 +1,8 @@
 package utils_test
 
 import (
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/dmitrymomot/go-utils"
@@ -17,14 +19,28 @@ func TestSanitizeEmail(t *testing.T) {
 		wantErr bool
 	}{
 		{"test@mail.dev", args{"test@mail.dev"}, "test@mail.dev", false},
-		{"trim plus", args{"test+1@mail.dev"}, "test@mail.dev", false},
 		{"trim spaces", args{" test@mail.dev  "}, "test@mail.dev", false},
-		{"trim plus", args{"test+test@mail.dev"}, "test@mail.dev", false},
-		{"trim dots", args{"tes.t@mail.dev"}, "test@mail.dev", false},
-		{"trim dots 2", args{"t.e.s.t@mail.dev"}, "test@mail.dev", false},
-		{"trim dash", args{"te-st@mail.dev"}, "test@mail.dev", false},
-		{"full test", args{"tes.t+23@mail.dev"}, "test@mail.dev", false},
+		{"lowercase", args{"Te.St+1@Mail.Dev"}, "te.st+1@mail.dev", false},
+		{"keep plus for unknown provider", args{"test+1@mail.dev"}, "test+1@mail.dev", false},
+		{"keep dots for unknown provider", args{"t.e.s.t@mail.dev"}, "t.e.s.t@mail.dev", false},
+		{"keep dash for unknown provider", args{"te-st@mail.dev"}, "te-st@mail.dev", false},
+		{"gmail trim plus", args{"test+test@gmail.com"}, "test@gmail.com", false},
+		{"gmail trim dots", args{"tes.t@gmail.com"}, "test@gmail.com", false},
+		{"gmail keep dash", args{"te-st@gmail.com"}, "te-st@gmail.com", false},
+		{"gmail full test", args{"tes.t+23@gmail.com"}, "test@gmail.com", false},
+		{"googlemail", args{"t.est+1@googlemail.com"}, "test@gmail.com", false},
+		{"outlook trim plus", args{"te.st+1@outlook.com"}, "te.st@outlook.com", false},
+		{"yahoo trim dash alias", args{"test-shopping@yahoo.com"}, "test@yahoo.com", false},
+		{"yahoo keep dots", args{"te.st@yahoo.com"}, "te.st@yahoo.com", false},
+		{"fastmail subdomain alias", args{"anything@test.fastmail.com"}, "test@fastmail.com", false},
+		{"fastmail trim plus", args{"test+1@fastmail.com"}, "test@fastmail.com", false},
+		{"gmail keep empty username", args{"+test@gmail.com"}, "+test@gmail.com", false},
+		{"idn domain", args{"josé@Bücher.de"}, "josé@bücher.de", false},
+		{"punycode domain", args{"jose@xn--bcher-kva.de"}, "jose@bücher.de", false},
+		{"unicode address", args{"用户@例子.中国"}, "用户@例子.中国", false},
+		{"nfc local part", args{"jose\u0301@bücher.de"}, "josé@bücher.de", false},
 		{"wrong email", args{"tes.t+23.mail.dev"}, "", true},
+		{"invalid suffix", args{"test@mail.invalidsuffix"}, "test@mail.invalidsuffix", true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -39,3 +55,78 @@ func TestSanitizeEmail(t *testing.T) {
 		})
 	}
 }
+
+func TestIsRoleEmail(t *testing.T) {
+	tests := []struct {
+		email string
+		want  bool
+	}{
+		{"admin@example.com", true},
+		{"Postmaster@example.com", true},
+		{"no-reply@example.com", true},
+		{"no.reply+bounce@example.com", true},
+		{"noreply@example.com", true},
+		{"abuse@example.com", true},
+		{"john@example.com", false},
+		{"administrator.john@example.com", false},
+		{"admin", false},
+	}
+	for _, tt := range tests {
+		if got := utils.IsRoleEmail(tt.email); got != tt.want {
+			t.Errorf("IsRoleEmail(%q) = %v, want %v", tt.email, got, tt.want)
+		}
+	}
+}
+
+func TestRoleEmailChecker(t *testing.T) {
+	c := utils.NewRoleEmailChecker()
+	if !c.IsRole("postmaster@example.com") {
+		t.Error("expected postmaster to be a role account")
+	}
+	if c.IsRole("ceo-office@example.com") {
+		t.Fatal("expected ceo-office not to be a role account")
+	}
+
+	c.Add("ceo-office")
+	if !c.IsRole("ceo.office@example.com") {
+		t.Error("expected ceo.office to be a role account")
+	}
+
+	if err := c.Load(strings.NewReader("# custom\nboard\n")); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !c.IsRole("board@example.com") {
+		t.Error("expected board to be a role account")
+	}
+
+	// The default list used by the package level helpers is not changed.
+	if utils.IsRoleEmail("board@example.com") {
+		t.Error("expected board not to be a role account in the default list")
+	}
+}
+
+func TestSanitizePersonalEmail(t *testing.T) {
+	tests := []struct {
+		name    string
+		email   string
+		want    string
+		wantErr error
+	}{
+		{"personal", "John@Example.com", "john@example.com", nil},
+		{"role", "Admin@Example.com", "admin@example.com", utils.ErrRoleEmailAddress},
+		{"role with tag", "noreply+x@gmail.com", "noreply@gmail.com", utils.ErrRoleEmailAddress},
+		{"invalid", "admin", "", utils.ErrInvalidEmailAddress},
+		{"invalid suffix", "admin@example.invalidsuffix", "admin@example.invalidsuffix", utils.ErrInvalidIcanSuffix},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := utils.SanitizePersonalEmail(tt.email)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SanitizePersonalEmail() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("SanitizePersonalEmail() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,300 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// Email address length limits (RFC 5321, section 4.5.3.1 and RFC 3696 errata).
+const (
+	EmailMaxLocalPartLength = 64
+	EmailMaxLength          = 254
+)
+
+// EmailIssueCode identifies a problem found by ValidateEmail.
+type EmailIssueCode string
+
+// Predefined email issue codes
+const (
+	EmailIssueSyntax           EmailIssueCode = "syntax"
+	EmailIssueLocalPartTooLong EmailIssueCode = "local_part_too_long"
+	EmailIssueTooLong          EmailIssueCode = "too_long"
+	EmailIssueInvalidSuffix    EmailIssueCode = "invalid_suffix"
+	EmailIssueDisposable       EmailIssueCode = "disposable"
+	EmailIssueRoleAccount      EmailIssueCode = "role_account"
+)
+
+// Email address parts referenced by EmailIssue.Field
+const (
+	EmailFieldAddress   = "address"
+	EmailFieldLocalPart = "local_part"
+	EmailFieldDomain    = "domain"
+)
+
+const (
+	// emailSyntaxUnknownPosition is used for issues not related to a specific character.
+	emailSyntaxUnknownPosition = -1
+	// emailAtextSpecials are the special characters allowed in an unquoted local part.
+	emailAtextSpecials = "!#$%&'*+-/=?^_`{|}~"
+)
+
+type (
+	// EmailIssue describes a single problem of an email address.
+	EmailIssue struct {
+		// Code is a machine readable issue code.
+		Code EmailIssueCode
+		// Field is the part of the address the issue relates to: "address", "local_part" or "domain".
+		Field string
+		// Position is the byte offset of the syntax error in the trimmed address, -1 if unknown or not applicable.
+		Position int
+		// Message is a human readable description of the issue.
+		Message string
+		// Err is the matching predefined error, e.g. ErrInvalidEmailAddress.
+		Err error
+	}
+
+	// EmailValidationResult is the result of ValidateEmail.
+	EmailValidationResult struct {
+		// Original is the address as it was passed to ValidateEmail.
+		Original string
+		// Normalized is the trimmed and lowercased address with the NFC normalized local part
+		// and the domain in Unicode form.
+		Normalized string
+		// Canonical is the address normalized with the provider rules, see SanitizeEmail.
+		Canonical string
+		// LocalPart is the normalized local part.
+		LocalPart string
+		// Domain is the domain in Unicode form.
+		Domain string
+		// ASCIIDomain is the domain in ASCII form (punycode).
+		ASCIIDomain string
+		// TLD is the public suffix of the domain, e.g. "com" or "co.uk".
+		TLD string
+		// SMTPUTF8 is true if the local part contains non-ASCII characters.
+		SMTPUTF8 bool
+		// Issues is the list of problems found, empty if the address is valid.
+		Issues []EmailIssue
+	}
+)
+
+// Error implements the error interface.
+func (i EmailIssue) Error() string {
+	return i.Message
+}
+
+// Unwrap returns the matching predefined error, so errors.Is can be used with the issue.
+func (i EmailIssue) Unwrap() error {
+	return i.Err
+}
+
+// Valid reports whether no issues were found.
+func (r EmailValidationResult) Valid() bool {
+	return len(r.Issues) == 0
+}
+
+// Has reports whether the result contains an issue with the given code.
+func (r EmailValidationResult) Has(code EmailIssueCode) bool {
+	for _, i := range r.Issues {
+		if i.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Err returns the first issue as an error or nil if the address is valid.
+func (r EmailValidationResult) Err() error {
+	if len(r.Issues) == 0 {
+		return nil
+	}
+	return r.Issues[0]
+}
+
+// ValidateEmail validates the email address and returns its normalized forms
+// together with all found issues: syntax errors with their position, length limits,
+// invalid public suffix, disposable domain and role account.
+func ValidateEmail(s string) EmailValidationResult {
+	result := EmailValidationResult{Original: s}
+	trimmed := strings.TrimSpace(s)
+
+	if issue := emailSyntaxIssue(trimmed); issue != nil {
+		result.Issues = append(result.Issues, *issue)
+		return result
+	}
+
+	email, err := ParseInternationalEmail(trimmed)
+	if err != nil {
+		result.Issues = append(result.Issues, EmailIssue{
+			Code:     EmailIssueSyntax,
+			Field:    EmailFieldAddress,
+			Position: emailSyntaxUnknownPosition,
+			Message:  "email address is malformed",
+			Err:      ErrInvalidEmailAddress,
+		})
+		return result
+	}
+
+	result.Normalized = email.String()
+	result.LocalPart = email.LocalPart
+	result.Domain = email.Domain
+	result.ASCIIDomain = email.ASCIIDomain
+	result.SMTPUTF8 = email.SMTPUTF8
+
+	if n := len(email.LocalPart); n > EmailMaxLocalPartLength {
+		result.Issues = append(result.Issues, EmailIssue{
+			Code:     EmailIssueLocalPartTooLong,
+			Field:    EmailFieldLocalPart,
+			Position: emailSyntaxUnknownPosition,
+			Message:  fmt.Sprintf("local part must be at most %d bytes long, got %d", EmailMaxLocalPartLength, n),
+			Err:      ErrInvalidEmailAddress,
+		})
+	}
+
+	if n := len(email.ASCII()); n > EmailMaxLength {
+		result.Issues = append(result.Issues, EmailIssue{
+			Code:     EmailIssueTooLong,
+			Field:    EmailFieldAddress,
+			Position: emailSyntaxUnknownPosition,
+			Message:  fmt.Sprintf("email address must be at most %d bytes long, got %d", EmailMaxLength, n),
+			Err:      ErrInvalidEmailAddress,
+		})
+	}
+
+	canonical, err := SanitizeEmail(trimmed)
+	result.Canonical = canonical
+	if errors.Is(err, ErrInvalidIcanSuffix) {
+		result.Issues = append(result.Issues, EmailIssue{
+			Code:     EmailIssueInvalidSuffix,
+			Field:    EmailFieldDomain,
+			Position: emailSyntaxUnknownPosition,
+			Message:  "domain suffix is not managed by ICANN",
+			Err:      ErrInvalidIcanSuffix,
+		})
+	}
+	result.TLD, _ = publicsuffix.PublicSuffix(email.ASCIIDomain)
+
+	if defaultEmailDomainChecker.ClassifyDomain(email.ASCIIDomain) == EmailDomainDisposable {
+		result.Issues = append(result.Issues, EmailIssue{
+			Code:     EmailIssueDisposable,
+			Field:    EmailFieldDomain,
+			Position: emailSyntaxUnknownPosition,
+			Message:  "disposable email addresses are not allowed",
+			Err:      ErrDisposableEmailAddress,
+		})
+	}
+
+	if IsRoleEmail(email.ASCII()) {
+		result.Issues = append(result.Issues, EmailIssue{
+			Code:     EmailIssueRoleAccount,
+			Field:    EmailFieldLocalPart,
+			Position: emailSyntaxUnknownPosition,
+			Message:  "role-based email addresses are not allowed",
+			Err:      ErrRoleEmailAddress,
+		})
+	}
+
+	return result
+}
+
+// emailSyntaxIssue returns the first syntax error of the address with its position, or nil.
+func emailSyntaxIssue(s string) *EmailIssue {
+	syntaxIssue := func(field string, pos int, format string, args ...interface{}) *EmailIssue {
+		return &EmailIssue{
+			Code:     EmailIssueSyntax,
+			Field:    field,
+			Position: pos,
+			Message:  fmt.Sprintf("%s at position %d", fmt.Sprintf(format, args...), pos),
+			Err:      ErrInvalidEmailAddress,
+		}
+	}
+
+	if !utf8.ValidString(s) {
+		return syntaxIssue(EmailFieldAddress, 0, "invalid UTF-8 sequence")
+	}
+
+	at := strings.LastIndex(s, "@")
+	if at < 0 {
+		return syntaxIssue(EmailFieldAddress, len(s), "missing @ sign")
+	}
+
+	local, domain := s[:at], s[at+1:]
+	if local == "" {
+		return syntaxIssue(EmailFieldLocalPart, 0, "local part is empty")
+	}
+	if domain == "" {
+		return syntaxIssue(EmailFieldDomain, len(s), "domain is empty")
+	}
+
+	if local[0] == '"' {
+		if len(local) < 2 || local[len(local)-1] != '"' {
+			return syntaxIssue(EmailFieldLocalPart, len(local)-1, "unterminated quoted string")
+		}
+		for i := 1; i < len(local)-1; i++ {
+			switch local[i] {
+			case '\\':
+				i++
+			case '"', '\r', '\n':
+				return syntaxIssue(EmailFieldLocalPart, i, "invalid character %q in quoted string", local[i])
+			}
+		}
+	} else {
+		for i, r := range local {
+			if r == '.' {
+				if i == 0 || i == len(local)-1 || local[i-1] == '.' {
+					return syntaxIssue(EmailFieldLocalPart, i, "unexpected dot")
+				}
+				continue
+			}
+			if !isEmailAtext(r) {
+				return syntaxIssue(EmailFieldLocalPart, i, "invalid character %q", r)
+			}
+		}
+	}
+
+	offset := at + 1
+	if domain[0] == '[' {
+		if domain[len(domain)-1] != ']' {
+			return syntaxIssue(EmailFieldDomain, len(s)-1, "unterminated domain literal")
+		}
+		return nil
+	}
+
+	if !strings.Contains(domain, ".") {
+		return syntaxIssue(EmailFieldDomain, len(s), "missing top level domain")
+	}
+
+	for _, label := range strings.Split(domain, ".") {
+		switch {
+		case label == "":
+			return syntaxIssue(EmailFieldDomain, offset, "empty domain label")
+		case len(label) > 63:
+			return syntaxIssue(EmailFieldDomain, offset, "domain label is longer than 63 bytes")
+		case label[0] == '-':
+			return syntaxIssue(EmailFieldDomain, offset, "domain label starts with a hyphen")
+		case label[len(label)-1] == '-':
+			return syntaxIssue(EmailFieldDomain, offset+len(label)-1, "domain label ends with a hyphen")
+		}
+		for i, r := range label {
+			if r != '-' && !isASCIIAlnum(r) && r < utf8.RuneSelf {
+				return syntaxIssue(EmailFieldDomain, offset+i, "invalid character %q", r)
+			}
+		}
+		offset += len(label) + 1
+	}
+
+	return nil
+}
+
+// isEmailAtext reports whether the rune is allowed in an unquoted local part (RFC 5322 atext, RFC 6532).
+func isEmailAtext(r rune) bool {
+	return isASCIIAlnum(r) || r >= utf8.RuneSelf || strings.ContainsRune(emailAtextSpecials, r)
+}
+
+// isASCIIAlnum reports whether the rune is an ASCII letter or digit.
+func isASCIIAlnum(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
@@ -0,0 +1,127 @@
+package utils_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dmitrymomot/go-utils"
+)
+
+func TestValidateEmail(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		r := utils.ValidateEmail(" Jo.hn+News@GoogleMail.com ")
+		if !r.Valid() {
+			t.Fatalf("expected valid address, got issues: %+v", r.Issues)
+		}
+		if r.Err() != nil {
+			t.Errorf("Err() = %v, want nil", r.Err())
+		}
+		if r.Original != " Jo.hn+News@GoogleMail.com " {
+			t.Errorf("Original = %q", r.Original)
+		}
+		if r.Normalized != "jo.hn+news@googlemail.com" {
+			t.Errorf("Normalized = %q", r.Normalized)
+		}
+		if r.Canonical != "john@gmail.com" {
+			t.Errorf("Canonical = %q", r.Canonical)
+		}
+		if r.LocalPart != "jo.hn+news" || r.Domain != "googlemail.com" || r.TLD != "com" {
+			t.Errorf("unexpected parts: %+v", r)
+		}
+	})
+
+	t.Run("idn", func(t *testing.T) {
+		r := utils.ValidateEmail("josé@bücher.de")
+		if !r.Valid() {
+			t.Fatalf("expected valid address, got issues: %+v", r.Issues)
+		}
+		if r.ASCIIDomain != "xn--bcher-kva.de" || r.TLD != "de" || !r.SMTPUTF8 {
+			t.Errorf("unexpected result: %+v", r)
+		}
+	})
+
+	t.Run("multiple issues", func(t *testing.T) {
+		r := utils.ValidateEmail("admin@mailinator.com")
+		if !r.Has(utils.EmailIssueDisposable) || !r.Has(utils.EmailIssueRoleAccount) {
+			t.Errorf("expected disposable and role issues, got %+v", r.Issues)
+		}
+		if !errors.Is(r.Err(), utils.ErrDisposableEmailAddress) {
+			t.Errorf("Err() = %v, want %v", r.Err(), utils.ErrDisposableEmailAddress)
+		}
+	})
+
+	t.Run("invalid suffix", func(t *testing.T) {
+		r := utils.ValidateEmail("john@example.invalidsuffix")
+		if !r.Has(utils.EmailIssueInvalidSuffix) {
+			t.Errorf("expected suffix issue, got %+v", r.Issues)
+		}
+		if !errors.Is(r.Err(), utils.ErrInvalidIcanSuffix) {
+			t.Errorf("Err() = %v, want %v", r.Err(), utils.ErrInvalidIcanSuffix)
+		}
+	})
+
+	t.Run("length limits", func(t *testing.T) {
+		r := utils.ValidateEmail(strings.Repeat("a", 65) + "@example.com")
+		if !r.Has(utils.EmailIssueLocalPartTooLong) {
+			t.Errorf("expected local part issue, got %+v", r.Issues)
+		}
+		if r.Has(utils.EmailIssueTooLong) {
+			t.Errorf("unexpected length issue: %+v", r.Issues)
+		}
+
+		domain := strings.Repeat(strings.Repeat("b", 50)+".", 5) + "com"
+		r = utils.ValidateEmail("john@" + domain)
+		if !r.Has(utils.EmailIssueTooLong) {
+			t.Errorf("expected length issue, got %+v", r.Issues)
+		}
+		if !errors.Is(r.Err(), utils.ErrInvalidEmailAddress) {
+			t.Errorf("Err() = %v, want %v", r.Err(), utils.ErrInvalidEmailAddress)
+		}
+	})
+}
+
+func TestValidateEmail_Syntax(t *testing.T) {
+	tests := []struct {
+		name     string
+		email    string
+		field    string
+		position int
+	}{
+		{"missing at", "john.example.com", utils.EmailFieldAddress, 16},
+		{"empty local part", "@example.com", utils.EmailFieldLocalPart, 0},
+		{"empty domain", "john@", utils.EmailFieldDomain, 5},
+		{"leading dot", ".john@example.com", utils.EmailFieldLocalPart, 0},
+		{"double dot", "jo..hn@example.com", utils.EmailFieldLocalPart, 3},
+		{"trailing dot", "john.@example.com", utils.EmailFieldLocalPart, 4},
+		{"space", "jo hn@example.com", utils.EmailFieldLocalPart, 2},
+		{"second at", "jo@hn@example.com", utils.EmailFieldLocalPart, 2},
+		{"unterminated quote", `"john@example.com`, utils.EmailFieldLocalPart, 4},
+		{"missing tld", "john@localhost", utils.EmailFieldDomain, 14},
+		{"empty label", "john@example..com", utils.EmailFieldDomain, 13},
+		{"leading hyphen", "john@-example.com", utils.EmailFieldDomain, 5},
+		{"trailing hyphen", "john@example-.com", utils.EmailFieldDomain, 12},
+		{"invalid domain char", "john@exa_mple.com", utils.EmailFieldDomain, 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := utils.ValidateEmail(tt.email)
+			if len(r.Issues) != 1 {
+				t.Fatalf("expected one issue, got %+v", r.Issues)
+			}
+			issue := r.Issues[0]
+			if issue.Code != utils.EmailIssueSyntax {
+				t.Errorf("Code = %v, want %v", issue.Code, utils.EmailIssueSyntax)
+			}
+			if issue.Field != tt.field {
+				t.Errorf("Field = %v, want %v", issue.Field, tt.field)
+			}
+			if issue.Position != tt.position {
+				t.Errorf("Position = %v, want %v (%s)", issue.Position, tt.position, issue.Message)
+			}
+			if !errors.Is(issue, utils.ErrInvalidEmailAddress) {
+				t.Errorf("expected issue to wrap %v", utils.ErrInvalidEmailAddress)
+			}
+		})
+	}
+}
@@ -4,7 +4,57 @@ import "errors"
 
 // Predefined errors
 var (
-	ErrInvalidReader   = errors.New("invalid reader")
-	ErrEmptyInput      = errors.New("empty input")
-	ErrInvalidPartSize = errors.New("part size must be greater than 0")
+	ErrInvalidReader             = errors.New("invalid reader")
+	ErrEmptyInput                = errors.New("empty input")
+	ErrInvalidPartSize           = errors.New("part size must be greater than 0")
+	ErrInvalidURL                = errors.New("invalid url")
+	ErrDownloadFailed            = errors.New("failed to download file")
+	ErrUnexpectedStatus          = errors.New("unexpected response status")
+	ErrFileTooLarge              = errors.New("file is too large")
+	ErrChecksumMismatch          = errors.New("checksum mismatch")
+	ErrInvalidWriter             = errors.New("invalid writer")
+	ErrUnsupportedScheme         = errors.New("this url schema is not supported")
+	ErrUploadTypeNotAllowed      = errors.New("file type is not allowed")
+	ErrUploadTypeMismatch        = errors.New("file type doesn't match its content")
+	ErrInvalidFileSize           = errors.New("file size must not be negative")
+	ErrUploadFailed              = errors.New("failed to upload file part")
+	ErrUnsupportedHash           = errors.New("unsupported hash algorithm")
+	ErrNoUniqueFileName          = errors.New("failed to generate unique file name")
+	ErrInvalidContentDisposition = errors.New("invalid content disposition")
+	ErrUnsupportedImage          = errors.New("unsupported image format")
+	ErrInvalidImage              = errors.New("invalid image header")
+	ErrInvalidArchive            = errors.New("invalid archive")
+	ErrUnsupportedArchive        = errors.New("unsupported archive format")
+	ErrUnsafeArchivePath         = errors.New("archive entry points outside of the destination")
+	ErrArchiveTooLarge           = errors.New("archive uncompressed size exceeds the limit")
+	ErrArchiveTooManyFiles       = errors.New("archive contains too many files")
+	ErrArchiveCompressionRatio   = errors.New("archive compression ratio exceeds the limit")
+	ErrInvalidFileSizeFormat     = errors.New("invalid file size format")
+	ErrFileSizeOverflow          = errors.New("file size is out of range")
+	ErrNotRegularFile            = errors.New("not a regular file")
+	ErrTooManyParts              = errors.New("file can't be split into the allowed number of parts")
 )
+
+// kindError is an error of the given kind, errors.Is matches both the kind and the wrapped error.
+// It's fmt.Errorf("%w: %w", kind, err) that works before Go 1.20.
+type kindError struct {
+	kind error
+	err  error
+}
+
+// wrapKind wraps err, so errors.Is(err, kind) is true.
+func wrapKind(kind, err error) error {
+	return &kindError{kind: kind, err: err}
+}
+
+func (e *kindError) Error() string {
+	return e.kind.Error() + ": " + e.err.Error()
+}
+
+func (e *kindError) Unwrap() error {
+	return e.err
+}
+
+func (e *kindError) Is(target error) bool {
+	return target == e.kind
+}
@@ -1,11 +1,10 @@
 package utils
 
 import (
+	"context"
 	"fmt"
 	"io"
-	"net/http"
 	"net/url"
-	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
@@ -16,37 +15,13 @@ import (
 // default mime type
 var defaultMimeType = "application/octet-stream"
 
-// PRedefined mime types map
-var mimeTypesMap = map[string]string{
-	"png":  "image/png",
-	"gif":  "image/gif",
-	"jpg":  "image/jpeg",
-	"jpeg": "image/jpeg",
-	"mp4":  "video/mp4",
-	"webm": "video/mp4",
-	"mkv":  "video/mp4",
-	"mov":  "video/quicktime",
-	"mp3":  "audio/mpeg",
-	"flac": "audio/flac",
-	"wav":  "audio/wav",
-	"glb":  "model/gltf-binary",
-	"gltf": "model/gltf+json",
-	"html": "text/html",
-	"js":   "application/javascript",
-	"css":  "text/css",
-	"json": "application/json",
-	"xml":  "application/xml",
-	"svg":  "image/svg+xml",
-	"ico":  "image/x-icon",
-	"zip":  "application/zip",
-	"pdf":  "application/pdf",
-	"txt":  "text/plain",
-	"md":   "text/markdown",
-	"csv":  "text/csv",
-	"docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
-}
+// maxFilePartsCount is the max number of parts returned by GetMaxFileParts.
+// It's kept at 100000 for compatibility and isn't a provider limit:
+// S3 accepts at most DefaultMaxParts parts, use PlanFileParts to plan S3 uploads.
+const maxFilePartsCount = 100000
 
-// GetFileTypeByURI returns the file type of the given URI
+// GetFileTypeByURI returns the file type of the given URI.
+// The file extension is looked up in DefaultMimeRegistry.
 func GetFileTypeByURI(uri string) string {
 	// Parse the uri.
 	parsedUri, err := url.Parse(uri)
@@ -68,58 +43,30 @@ func GetFileTypeByURI(uri string) string {
 		ext = strings.Split(ext, "?")[0]
 	}
 
-	// get the mime type from the map.
-	mime, ok := mimeTypesMap[strings.Trim(ext, ".")]
-	if !ok {
-		return defaultMimeType
-	}
-
-	return mime
+	// get the mime type from the registry.
+	return GetMimeTypeByExtension(ext)
 }
 
 // GetFileByPath returns the file bytes from the given path.
 // If the path is a URL, it will download the file and return the bytes.
 // If the path is a local file, it will read the file and return the bytes.
+// Other URI schemes can be added with RegisterFileLoader, see GetFileByPathWithContext.
+// It gives up after DefaultDownloadFileTimeout.
 func GetFileByPath(path string) ([]byte, error) {
-	if path == "" {
-		return nil, fmt.Errorf("path cannot be empty")
-	}
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultDownloadFileTimeout)
+	defer cancel()
 
-	if strings.Contains(path, "://") {
-		if !strings.HasPrefix(path, "http") {
-			return nil, fmt.Errorf("this url schema is not supported: %s", path)
-		}
-
-		b, err := DownloadFile(path)
-		if err != nil {
-			return nil, fmt.Errorf("failed to download file from url: %w", err)
-		}
-
-		return b, nil
-	}
-
-	b, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read file from local disk: %w", err)
-	}
-
-	return b, nil
+	return GetFileByPathWithContext(ctx, path)
 }
 
 // DownloadFile downloads the file from the given URL and returns the bytes.
+// It's a shortcut for DownloadFileWithContext with default options,
+// the download is canceled after DefaultDownloadFileTimeout.
 func DownloadFile(url string) ([]byte, error) {
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	b, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultDownloadFileTimeout)
+	defer cancel()
 
-	return b, nil
+	return DownloadFileWithContext(ctx, url)
 }
 
 // GetFileContentType returns the content type of a file.
@@ -149,7 +96,8 @@ func GetFileContentTypeByBytes(input []byte) (string, error) {
 }
 
 // Get max file parts can be if the file is split into parts with the given part size.
-// The max file parts is 10000.
+// The result is capped at 100000, which is more than S3 accepts (DefaultMaxParts),
+// see PlanFileParts for S3-style limits.
 // file io.ReadSeeker: the file to be uploaded.
 // partSize int64: the part size.
 func GetMaxFileParts(file io.ReadSeeker, partSize int64) (int64, error) {
@@ -172,9 +120,9 @@ func GetMaxFileParts(file io.ReadSeeker, partSize int64) (int64, error) {
 		maxFileParts++
 	}
 
-	// Limit the number of parts to 100000.
-	if maxFileParts > 100000 {
-		maxFileParts = 100000
+	// Limit the number of parts.
+	if maxFileParts > maxFilePartsCount {
+		maxFileParts = maxFilePartsCount
 	}
 
 	return maxFileParts, nil
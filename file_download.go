@@ -0,0 +1,500 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default download settings.
+const (
+	DefaultDownloadDialTimeout = 30 * time.Second
+	DefaultDownloadTLSTimeout  = 10 * time.Second
+	// DefaultDownloadIdleTimeout limits waiting for the response headers and for each read of the body.
+	DefaultDownloadIdleTimeout = 60 * time.Second
+	DefaultDownloadMinBackoff  = 500 * time.Millisecond
+	DefaultDownloadMaxBackoff  = 30 * time.Second
+	// DefaultDownloadFileTimeout limits the whole download of DownloadFile and GetFileByPath,
+	// which don't take a context. A server trickling bytes never hits the idle timeout.
+	DefaultDownloadFileTimeout = 10 * time.Minute
+)
+
+// defaultDownloadClient is used when no HTTP client is provided.
+var defaultDownloadClient = NewDownloadHTTPClient(DefaultDownloadIdleTimeout)
+
+// errDownloadNotRestartable is returned when a download has to start over
+// but the already written data can't be discarded.
+var errDownloadNotRestartable = errors.New("download can't be restarted: writer can't be reset")
+
+type (
+	// DownloadOption configures file downloads.
+	DownloadOption func(*downloadOptions)
+
+	downloadOptions struct {
+		client     *http.Client
+		maxSize    int64
+		retries    int
+		minBackoff time.Duration
+		maxBackoff time.Duration
+		newHash    func() hash.Hash
+		checksum   string
+	}
+
+	// HTTPStatusError is returned when the server responds with a non-2xx status code.
+	HTTPStatusError struct {
+		URL        string
+		StatusCode int
+		Status     string
+	}
+
+	// idleTimeoutTransport cancels the request if the server sends no data for too long.
+	idleTimeoutTransport struct {
+		base    http.RoundTripper
+		timeout time.Duration
+	}
+
+	// idleTimeoutBody arms the timer only while a read is waiting for data,
+	// so a slow consumer doesn't trigger it.
+	idleTimeoutBody struct {
+		io.ReadCloser
+		ctx     context.Context
+		timeout time.Duration
+		timer   *time.Timer
+		cancel  context.CancelFunc
+	}
+
+	// downloadState tracks the progress of a download across retries.
+	downloadState struct {
+		w         io.Writer
+		hash      hash.Hash
+		maxSize   int64
+		written   int64
+		validator string
+	}
+)
+
+// Error implements the error interface.
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrUnexpectedStatus.Error(), e.Status)
+}
+
+// Unwrap makes errors.Is(err, ErrUnexpectedStatus) work.
+func (e *HTTPStatusError) Unwrap() error {
+	return ErrUnexpectedStatus
+}
+
+// NewDownloadHTTPClient returns an HTTP client suitable for downloads of any size.
+// Connecting and the TLS handshake are limited (see DefaultDownloadDialTimeout and DefaultDownloadTLSTimeout),
+// the server may not stay silent for longer than idleTimeout: neither before the response headers
+// nor between reads of the body. The total download time is not limited, so a large file isn't cut off
+// mid-download. Use the context to limit the whole download.
+func NewDownloadHTTPClient(idleTimeout time.Duration) *http.Client {
+	var t *http.Transport
+	if dt, ok := http.DefaultTransport.(*http.Transport); ok {
+		t = dt.Clone()
+	} else {
+		t = &http.Transport{Proxy: http.ProxyFromEnvironment}
+	}
+
+	t.DialContext = (&net.Dialer{
+		Timeout:   DefaultDownloadDialTimeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	t.TLSHandshakeTimeout = DefaultDownloadTLSTimeout
+	t.ResponseHeaderTimeout = idleTimeout
+
+	return &http.Client{Transport: &idleTimeoutTransport{base: t, timeout: idleTimeout}}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *idleTimeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(req.Context())
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	timer := time.AfterFunc(t.timeout, cancel)
+	timer.Stop()
+	resp.Body = &idleTimeoutBody{ReadCloser: resp.Body, ctx: req.Context(), timeout: t.timeout, timer: timer, cancel: cancel}
+	return resp, nil
+}
+
+func (b *idleTimeoutBody) Read(p []byte) (int, error) {
+	b.timer.Reset(b.timeout)
+	n, err := b.ReadCloser.Read(p)
+	if !b.timer.Stop() && err != nil && b.ctx.Err() == nil {
+		err = fmt.Errorf("no data received for %s: %w", b.timeout, os.ErrDeadlineExceeded)
+	}
+	return n, err
+}
+
+func (b *idleTimeoutBody) Close() error {
+	b.timer.Stop()
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// WithHTTPClient sets the HTTP client used for downloads.
+// Defaults to NewDownloadHTTPClient with DefaultDownloadIdleTimeout.
+// Note that http.Client.Timeout includes reading the body, so it limits the max file size
+// that can be downloaded over a slow connection.
+func WithHTTPClient(c *http.Client) DownloadOption {
+	return func(o *downloadOptions) {
+		if c != nil {
+			o.client = c
+		}
+	}
+}
+
+// WithMaxDownloadSize sets the max number of bytes to download.
+// Zero or negative value means no limit, which is the default.
+func WithMaxDownloadSize(n int64) DownloadOption {
+	return func(o *downloadOptions) {
+		o.maxSize = n
+	}
+}
+
+// WithRetries sets how many times a failed download is retried.
+// Network errors, 408, 429 and 5xx responses are retried. Defaults to 0.
+func WithRetries(n int) DownloadOption {
+	return func(o *downloadOptions) {
+		if n >= 0 {
+			o.retries = n
+		}
+	}
+}
+
+// WithRetryBackoff sets the min and max delay between retries.
+// The delay is doubled after each attempt, up to the max delay.
+func WithRetryBackoff(minDelay, maxDelay time.Duration) DownloadOption {
+	return func(o *downloadOptions) {
+		if minDelay > 0 {
+			o.minBackoff = minDelay
+		}
+		if maxDelay >= o.minBackoff {
+			o.maxBackoff = maxDelay
+		}
+	}
+}
+
+// WithChecksum verifies the digest of the downloaded content.
+// expected is the hex encoded digest, ErrChecksumMismatch is returned if it doesn't match.
+// Empty expected value disables the verification.
+func WithChecksum(newHash func() hash.Hash, expected string) DownloadOption {
+	return func(o *downloadOptions) {
+		o.newHash, o.checksum = nil, strings.TrimSpace(expected)
+		if o.checksum != "" {
+			o.newHash = newHash
+		}
+	}
+}
+
+// WithSHA256Checksum verifies the hex encoded SHA-256 digest of the downloaded content.
+func WithSHA256Checksum(expected string) DownloadOption {
+	return WithChecksum(sha256.New, expected)
+}
+
+// WithMD5Checksum verifies the hex encoded MD5 digest of the downloaded content.
+func WithMD5Checksum(expected string) DownloadOption {
+	return WithChecksum(md5.New, expected)
+}
+
+// newDownloadOptions returns download options with defaults applied.
+func newDownloadOptions(opts ...DownloadOption) *downloadOptions {
+	o := &downloadOptions{
+		client:     defaultDownloadClient,
+		minBackoff: DefaultDownloadMinBackoff,
+		maxBackoff: DefaultDownloadMaxBackoff,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// backoff returns the delay before the given retry attempt, starting from 1.
+func (o *downloadOptions) backoff(attempt int) time.Duration {
+	return retryBackoff(attempt, o.minBackoff, o.maxBackoff)
+}
+
+// retryBackoff returns the exponential delay with jitter before the given retry attempt, starting from 1.
+func retryBackoff(attempt int, minDelay, maxDelay time.Duration) time.Duration {
+	d := minDelay
+	for i := 1; i < attempt && d < maxDelay; i++ {
+		d *= 2
+	}
+	if d > maxDelay {
+		d = maxDelay
+	}
+
+	// Add jitter, so concurrent clients don't retry at the same time.
+	half := int64(d / 2)
+	if half <= 0 {
+		return d
+	}
+	return time.Duration(half + rand.Int63n(half+1))
+}
+
+// DownloadFileWithContext downloads the file from the given URL and returns the bytes.
+// The request is canceled when the context is done.
+// Possible errors: ErrInvalidURL, ErrDownloadFailed, *HTTPStatusError (ErrUnexpectedStatus),
+// ErrFileTooLarge and ErrChecksumMismatch.
+func DownloadFileWithContext(ctx context.Context, rawURL string, opts ...DownloadOption) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := DownloadToWriter(ctx, rawURL, &buf, opts...); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DownloadToWriter streams the file from the given URL into the writer and returns the number of written bytes.
+// Failed attempts are retried with exponential backoff (see WithRetries). If the server supports
+// range requests, the download is resumed from the last written byte using Range and If-Range headers.
+// Otherwise the download starts over, which requires the writer to be resettable:
+// a *bytes.Buffer or a file (io.Seeker with Truncate method), e.g. *os.File.
+func DownloadToWriter(ctx context.Context, rawURL string, w io.Writer, opts ...DownloadOption) (int64, error) {
+	if w == nil {
+		return 0, ErrInvalidWriter
+	}
+
+	o := newDownloadOptions(opts...)
+	st := &downloadState{w: w, maxSize: o.maxSize}
+	if o.newHash != nil {
+		st.hash = o.newHash()
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := st.download(ctx, o, rawURL)
+		if err == nil {
+			break
+		}
+		if attempt >= o.retries || !isRetryableDownloadError(ctx, err) {
+			return st.written, err
+		}
+
+		timer := time.NewTimer(o.backoff(attempt + 1))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return st.written, wrapKind(ErrDownloadFailed, ctx.Err())
+		case <-timer.C:
+		}
+	}
+
+	if st.hash != nil {
+		sum := hex.EncodeToString(st.hash.Sum(nil))
+		if !strings.EqualFold(sum, o.checksum) {
+			return st.written, fmt.Errorf("%w: expected %s, got %s", ErrChecksumMismatch, o.checksum, sum)
+		}
+	}
+
+	return st.written, nil
+}
+
+// DownloadToTempFile downloads the file from the given URL into a new temporary file
+// created with os.CreateTemp(dir, pattern). The returned file is positioned at the beginning,
+// the caller is responsible for closing and removing it.
+func DownloadToTempFile(ctx context.Context, rawURL, dir, pattern string, opts ...DownloadOption) (*os.File, error) {
+	f, err := os.CreateTemp(dir, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+
+	cleanup := func() {
+		f.Close()
+		os.Remove(f.Name())
+	}
+
+	if _, err := DownloadToWriter(ctx, rawURL, f, opts...); err != nil {
+		cleanup()
+		return nil, err
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		cleanup()
+		return nil, fmt.Errorf("failed to reset file read position: %w", err)
+	}
+
+	return f, nil
+}
+
+// download makes a single download attempt, resuming from the last written byte if possible.
+func (st *downloadState) download(ctx context.Context, o *downloadOptions, rawURL string) error {
+	header := http.Header{}
+	if st.written > 0 && st.validator != "" {
+		header.Set("Range", fmt.Sprintf("bytes=%d-", st.written))
+		header.Set("If-Range", st.validator)
+	}
+
+	resp, err := openDownload(ctx, o, rawURL, header)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusPartialContent {
+		if start, ok := parseContentRangeStart(resp.Header.Get("Content-Range")); !ok || start != st.written {
+			return fmt.Errorf("%w: unexpected content range %q", ErrDownloadFailed, resp.Header.Get("Content-Range"))
+		}
+	} else {
+		// Full content: the first attempt, or the server can't resume the download.
+		if err := st.reset(); err != nil {
+			return err
+		}
+		st.validator = ""
+		if resp.Header.Get("Accept-Ranges") == "bytes" {
+			st.validator = downloadValidator(resp.Header)
+		}
+	}
+
+	if _, err := io.Copy(st, resp.Body); err != nil {
+		if errors.Is(err, ErrFileTooLarge) {
+			return err
+		}
+		return wrapKind(ErrDownloadFailed, fmt.Errorf("failed to read response body: %w", err))
+	}
+
+	return nil
+}
+
+// Write implements io.Writer, it enforces the size limit and updates the checksum.
+func (st *downloadState) Write(p []byte) (int, error) {
+	if st.maxSize > 0 && st.written+int64(len(p)) > st.maxSize {
+		return 0, fmt.Errorf("%w: limit is %d bytes", ErrFileTooLarge, st.maxSize)
+	}
+
+	n, err := st.w.Write(p)
+	if st.hash != nil {
+		st.hash.Write(p[:n])
+	}
+	st.written += int64(n)
+
+	return n, err
+}
+
+// reset discards already written data.
+func (st *downloadState) reset() error {
+	if st.written == 0 {
+		return nil
+	}
+
+	switch w := st.w.(type) {
+	case interface{ Reset() }:
+		w.Reset()
+	case interface {
+		io.Seeker
+		Truncate(size int64) error
+	}:
+		if _, err := w.Seek(0, io.SeekStart); err != nil {
+			return wrapKind(errDownloadNotRestartable, err)
+		}
+		if err := w.Truncate(0); err != nil {
+			return wrapKind(errDownloadNotRestartable, err)
+		}
+	default:
+		return errDownloadNotRestartable
+	}
+
+	st.written = 0
+	if st.hash != nil {
+		st.hash.Reset()
+	}
+
+	return nil
+}
+
+// openDownload sends a GET request and checks the response status code.
+// The caller must close the response body.
+func openDownload(ctx context.Context, o *downloadOptions, rawURL string, header http.Header) (*http.Response, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidURL, rawURL)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidURL, err.Error())
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, wrapKind(ErrDownloadFailed, err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, &HTTPStatusError{URL: rawURL, StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	if o.maxSize > 0 && resp.ContentLength > o.maxSize {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %d bytes, limit is %d", ErrFileTooLarge, resp.ContentLength, o.maxSize)
+	}
+
+	return resp, nil
+}
+
+// isRetryableDownloadError reports whether the failed download attempt can be retried.
+func isRetryableDownloadError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return isRetryableStatus(statusErr.StatusCode)
+	}
+
+	return errors.Is(err, ErrDownloadFailed) && !errors.Is(err, errDownloadNotRestartable)
+}
+
+// isRetryableStatus reports whether the request failed with a temporary error status: 408, 429 or 5xx.
+func isRetryableStatus(code int) bool {
+	return code >= 500 ||
+		code == http.StatusTooManyRequests ||
+		code == http.StatusRequestTimeout
+}
+
+// downloadValidator returns the value for the If-Range header: a strong ETag or Last-Modified date.
+func downloadValidator(h http.Header) string {
+	if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return h.Get("Last-Modified")
+}
+
+// parseContentRangeStart returns the first byte position of the "bytes start-end/size" header value.
+func parseContentRangeStart(s string) (int64, bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "bytes ")
+	i := strings.Index(s, "-")
+	if i <= 0 {
+		return 0, false
+	}
+
+	start, err := strconv.ParseInt(s[:i], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return start, true
+}
@@ -0,0 +1,372 @@
+package utils_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dmitrymomot/go-utils"
+)
+
+func TestDownloadFileWithContext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/file.txt":
+			_, _ = w.Write([]byte("test file content"))
+		case "/large":
+			_, _ = w.Write([]byte(strings.Repeat("a", 1024)))
+		case "/chunked":
+			w.(http.Flusher).Flush()
+			_, _ = w.Write([]byte(strings.Repeat("a", 1024)))
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+			_, _ = w.Write([]byte("slow"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	t.Run("success", func(t *testing.T) {
+		data, err := utils.DownloadFileWithContext(context.Background(), srv.URL+"/file.txt", utils.WithHTTPClient(srv.Client()))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if string(data) != "test file content" {
+			t.Errorf("got wrong data: %s", data)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := utils.DownloadFileWithContext(context.Background(), srv.URL+"/missing")
+		if !errors.Is(err, utils.ErrUnexpectedStatus) {
+			t.Fatalf("expected error %v, got %v", utils.ErrUnexpectedStatus, err)
+		}
+		var statusErr *utils.HTTPStatusError
+		if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+			t.Errorf("expected status error with code 404, got %v", err)
+		}
+	})
+
+	t.Run("too large by content length", func(t *testing.T) {
+		_, err := utils.DownloadFileWithContext(context.Background(), srv.URL+"/large", utils.WithMaxDownloadSize(100))
+		if !errors.Is(err, utils.ErrFileTooLarge) {
+			t.Errorf("expected error %v, got %v", utils.ErrFileTooLarge, err)
+		}
+	})
+
+	t.Run("too large without content length", func(t *testing.T) {
+		_, err := utils.DownloadFileWithContext(context.Background(), srv.URL+"/chunked", utils.WithMaxDownloadSize(100))
+		if !errors.Is(err, utils.ErrFileTooLarge) {
+			t.Errorf("expected error %v, got %v", utils.ErrFileTooLarge, err)
+		}
+	})
+
+	t.Run("within limit", func(t *testing.T) {
+		data, err := utils.DownloadFileWithContext(context.Background(), srv.URL+"/large", utils.WithMaxDownloadSize(1024))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(data) != 1024 {
+			t.Errorf("expected 1024 bytes, got %d", len(data))
+		}
+	})
+
+	t.Run("context timeout", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		_, err := utils.DownloadFileWithContext(ctx, srv.URL+"/slow")
+		if !errors.Is(err, utils.ErrDownloadFailed) || !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected timeout error, got %v", err)
+		}
+	})
+
+	t.Run("client timeout", func(t *testing.T) {
+		client := &http.Client{Timeout: 20 * time.Millisecond}
+		_, err := utils.DownloadFileWithContext(context.Background(), srv.URL+"/slow", utils.WithHTTPClient(client))
+		if !errors.Is(err, utils.ErrDownloadFailed) {
+			t.Errorf("expected error %v, got %v", utils.ErrDownloadFailed, err)
+		}
+	})
+
+	t.Run("invalid url", func(t *testing.T) {
+		for _, u := range []string{"invalid-url", "ftp://example.com/file.txt", "http://"} {
+			_, err := utils.DownloadFileWithContext(context.Background(), u)
+			if !errors.Is(err, utils.ErrInvalidURL) {
+				t.Errorf("%s: expected error %v, got %v", u, utils.ErrInvalidURL, err)
+			}
+		}
+	})
+}
+
+// flakyFileServer serves the content, but aborts the first n responses halfway.
+func TestNewDownloadHTTPClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow-headers" {
+			time.Sleep(300 * time.Millisecond)
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+
+		if r.URL.Path == "/stalled" {
+			_, _ = w.Write([]byte("chunk"))
+			w.(http.Flusher).Flush()
+			time.Sleep(300 * time.Millisecond)
+			return
+		}
+
+		// The body streams for longer than the header timeout.
+		for i := 0; i < 5; i++ {
+			time.Sleep(60 * time.Millisecond)
+			_, _ = w.Write([]byte("chunk"))
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer srv.Close()
+
+	client := utils.NewDownloadHTTPClient(100 * time.Millisecond)
+
+	t.Run("slow body", func(t *testing.T) {
+		data, err := utils.DownloadFileWithContext(context.Background(), srv.URL+"/stream", utils.WithHTTPClient(client))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if string(data) != strings.Repeat("chunk", 5) {
+			t.Errorf("got wrong data: %s", data)
+		}
+	})
+
+	t.Run("stalled body", func(t *testing.T) {
+		start := time.Now()
+		_, err := utils.DownloadFileWithContext(context.Background(), srv.URL+"/stalled",
+			utils.WithHTTPClient(client), utils.WithRetries(0))
+		if !errors.Is(err, utils.ErrDownloadFailed) {
+			t.Errorf("expected error %v, got %v", utils.ErrDownloadFailed, err)
+		}
+		if elapsed := time.Since(start); elapsed > 250*time.Millisecond {
+			t.Errorf("idle timeout took %s", elapsed)
+		}
+	})
+
+	t.Run("slow consumer", func(t *testing.T) {
+		resp, err := client.Get(srv.URL + "/stream")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer resp.Body.Close()
+
+		// Time between reads doesn't count as idle.
+		buf := make([]byte, 5)
+		for i := 0; i < 2; i++ {
+			if _, err := io.ReadFull(resp.Body, buf); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			time.Sleep(150 * time.Millisecond)
+		}
+	})
+
+	t.Run("slow headers", func(t *testing.T) {
+		_, err := utils.DownloadFileWithContext(context.Background(), srv.URL+"/slow-headers",
+			utils.WithHTTPClient(client), utils.WithRetries(0))
+		if err == nil {
+			t.Fatal("expected timeout error, got nil")
+		}
+	})
+
+	t.Run("context deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		_, err := utils.DownloadFileWithContext(ctx, srv.URL+"/stream", utils.WithHTTPClient(client))
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected error %v, got %v", context.DeadlineExceeded, err)
+		}
+	})
+}
+
+func flakyFileServer(t *testing.T, content string, n int, ranges bool) (*httptest.Server, *[]string) {
+	t.Helper()
+
+	var requestedRanges []string
+	modTime := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestedRanges = append(requestedRanges, r.Header.Get("Range"))
+
+		if !ranges {
+			r.Header.Del("Range")
+		} else {
+			w.Header().Set("ETag", `"v1"`)
+		}
+
+		if n > 0 {
+			n--
+			start := 0
+			if rng := r.Header.Get("Range"); rng != "" {
+				_, _ = fmt.Sscanf(rng, "bytes=%d-", &start)
+				w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(content)-1, len(content)))
+				w.Header().Set("Accept-Ranges", "bytes")
+				w.Header().Set("Content-Length", strconv.Itoa(len(content)-start))
+				w.WriteHeader(http.StatusPartialContent)
+			} else {
+				if ranges {
+					w.Header().Set("Accept-Ranges", "bytes")
+				}
+				w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			}
+			rest := content[start:]
+			_, _ = w.Write([]byte(rest[:len(rest)/2]))
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+
+		if !ranges {
+			_, _ = w.Write([]byte(content))
+			return
+		}
+		http.ServeContent(w, r, "file.txt", modTime, strings.NewReader(content))
+	}))
+
+	return srv, &requestedRanges
+}
+
+func TestDownloadToWriter(t *testing.T) {
+	content := strings.Repeat("0123456789", 100)
+	sum := sha256.Sum256([]byte(content))
+	checksum := hex.EncodeToString(sum[:])
+	backoff := utils.WithRetryBackoff(time.Millisecond, 5*time.Millisecond)
+
+	t.Run("resume with range request", func(t *testing.T) {
+		srv, ranges := flakyFileServer(t, content, 2, true)
+		defer srv.Close()
+
+		var buf bytes.Buffer
+		n, err := utils.DownloadToWriter(context.Background(), srv.URL, &buf, utils.WithRetries(3), backoff, utils.WithSHA256Checksum(checksum))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if n != int64(len(content)) || buf.String() != content {
+			t.Errorf("got wrong content (%d bytes)", n)
+		}
+		if len(*ranges) != 3 || (*ranges)[0] != "" || (*ranges)[1] != "bytes=500-" || (*ranges)[2] != "bytes=750-" {
+			t.Errorf("unexpected range requests: %q", *ranges)
+		}
+	})
+
+	t.Run("restart without range support", func(t *testing.T) {
+		srv, ranges := flakyFileServer(t, content, 1, false)
+		defer srv.Close()
+
+		var buf bytes.Buffer
+		_, err := utils.DownloadToWriter(context.Background(), srv.URL, &buf, utils.WithRetries(1), backoff, utils.WithSHA256Checksum(checksum))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if buf.String() != content {
+			t.Errorf("got wrong content")
+		}
+		if len(*ranges) != 2 || (*ranges)[1] != "" {
+			t.Errorf("unexpected range requests: %q", *ranges)
+		}
+	})
+
+	t.Run("restart with not resettable writer", func(t *testing.T) {
+		srv, _ := flakyFileServer(t, content, 1, false)
+		defer srv.Close()
+
+		var buf bytes.Buffer
+		w := struct{ io.Writer }{&buf}
+		_, err := utils.DownloadToWriter(context.Background(), srv.URL, w, utils.WithRetries(1), backoff)
+		if err == nil {
+			t.Fatal("expected error but got none")
+		}
+	})
+
+	t.Run("retries exhausted", func(t *testing.T) {
+		srv, ranges := flakyFileServer(t, content, 5, true)
+		defer srv.Close()
+
+		var buf bytes.Buffer
+		_, err := utils.DownloadToWriter(context.Background(), srv.URL, &buf, utils.WithRetries(2), backoff)
+		if !errors.Is(err, utils.ErrDownloadFailed) {
+			t.Errorf("expected error %v, got %v", utils.ErrDownloadFailed, err)
+		}
+		if len(*ranges) != 3 {
+			t.Errorf("expected 3 attempts, got %d", len(*ranges))
+		}
+	})
+
+	t.Run("retry server errors only", func(t *testing.T) {
+		var calls int
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if calls == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		defer srv.Close()
+
+		var buf bytes.Buffer
+		_, err := utils.DownloadToWriter(context.Background(), srv.URL, &buf, utils.WithRetries(5), backoff)
+		var statusErr *utils.HTTPStatusError
+		if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusForbidden {
+			t.Errorf("expected 403 status error, got %v", err)
+		}
+		if calls != 2 {
+			t.Errorf("expected 2 attempts, got %d", calls)
+		}
+	})
+
+	t.Run("checksum mismatch", func(t *testing.T) {
+		srv, _ := flakyFileServer(t, content, 0, true)
+		defer srv.Close()
+
+		var buf bytes.Buffer
+		_, err := utils.DownloadToWriter(context.Background(), srv.URL, &buf, utils.WithMD5Checksum("d41d8cd98f00b204e9800998ecf8427e"))
+		if !errors.Is(err, utils.ErrChecksumMismatch) {
+			t.Errorf("expected error %v, got %v", utils.ErrChecksumMismatch, err)
+		}
+	})
+
+	t.Run("nil writer", func(t *testing.T) {
+		_, err := utils.DownloadToWriter(context.Background(), "http://example.com", nil)
+		if !errors.Is(err, utils.ErrInvalidWriter) {
+			t.Errorf("expected error %v, got %v", utils.ErrInvalidWriter, err)
+		}
+	})
+}
+
+func TestDownloadToTempFile(t *testing.T) {
+	content := strings.Repeat("0123456789", 100)
+	srv, _ := flakyFileServer(t, content, 1, false)
+	defer srv.Close()
+
+	f, err := utils.DownloadToTempFile(context.Background(), srv.URL, t.TempDir(), "download-*.txt",
+		utils.WithRetries(1), utils.WithRetryBackoff(time.Millisecond, time.Millisecond))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatalf("failed to read temp file: %v", err)
+	}
+	if string(data) != content {
+		t.Errorf("got wrong content (%d bytes)", len(data))
+	}
+}
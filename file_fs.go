@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+)
+
+// WalkFileFunc is called by WalkFilesFS for each matching file.
+// name is the slash-separated path of the file in the file system.
+// Returning fs.SkipDir skips the rest of the file's directory.
+type WalkFileFunc func(name, contentType string, d fs.DirEntry) error
+
+// GetFileByPathFS returns the file bytes from the given file system, e.g. embed.FS or fstest.MapFS.
+// A leading slash of the name is ignored.
+func GetFileByPathFS(fsys fs.FS, name string) ([]byte, error) {
+	b, err := fs.ReadFile(fsys, fsFileName(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file from file system: %w", err)
+	}
+	return b, nil
+}
+
+// GetFileSizeFS returns the size of the file in the given file system.
+func GetFileSizeFS(fsys fs.FS, name string) (int64, error) {
+	info, err := fs.Stat(fsys, fsFileName(name))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get file size: %w", err)
+	}
+	if !info.Mode().IsRegular() {
+		return 0, fmt.Errorf("%w: %s", ErrNotRegularFile, name)
+	}
+	return info.Size(), nil
+}
+
+// GetFileContentTypeFS returns the content type of the file in the given file system.
+// The type is detected by the file content, and by the file name extension
+// if the content isn't recognized.
+func GetFileContentTypeFS(fsys fs.FS, name string) (string, error) {
+	name = fsFileName(name)
+
+	f, err := fsys.Open(name)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file from file system: %w", err)
+	}
+	defer f.Close()
+
+	return fsFileContentType(f, name)
+}
+
+// WalkFilesFS walks the file tree rooted at root, calling fn for each regular file
+// whose content type matches one of the types, e.g. "image/png" or "image/*".
+// Files of any type are passed to fn if no types are given.
+// Content types are detected like GetFileContentTypeFS does.
+func WalkFilesFS(fsys fs.FS, root string, fn WalkFileFunc, types ...string) error {
+	root = fsFileName(root)
+	if root == "" {
+		root = "."
+	}
+
+	return fs.WalkDir(fsys, root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		contentType, err := GetFileContentTypeFS(fsys, name)
+		if err != nil {
+			return err
+		}
+		if !mimeTypeMatches(types, contentType) {
+			return nil
+		}
+
+		return fn(name, contentType, d)
+	})
+}
+
+// fsFileContentType detects the content type of the file by its first bytes and name.
+func fsFileContentType(r io.Reader, name string) (string, error) {
+	head := make([]byte, fileStreamSniffLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+
+	contentType := defaultMimeType
+	if n > 0 {
+		contentType, _ = GetFileContentTypeByBytes(head[:n])
+	}
+	if contentType == defaultMimeType {
+		contentType = GetFileTypeByURI(name)
+	}
+
+	return contentType, nil
+}
+
+// fsFileName converts the name to a valid fs.FS path by trimming the leading slash.
+func fsFileName(name string) string {
+	return strings.TrimPrefix(name, "/")
+}
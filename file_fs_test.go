@@ -0,0 +1,180 @@
+package utils_test
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"io/fs"
+	"sort"
+	"testing"
+	"testing/fstest"
+
+	"github.com/dmitrymomot/go-utils"
+)
+
+func testFS(t *testing.T) fstest.MapFS {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+
+	return fstest.MapFS{
+		"static/logo.png":       {Data: buf.Bytes()},
+		"static/icons/star.png": {Data: buf.Bytes()},
+		"static/icon.svg":       {Data: []byte(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`)},
+		"static/app.css":        {Data: []byte("body { margin: 0; }")},
+		"static/blob.bin":       {Data: []byte{0x00, 0x01, 0x02}},
+		"static/empty.txt":      {Data: nil},
+		"templates/index.html":  {Data: []byte("<!DOCTYPE html><html></html>")},
+	}
+}
+
+func TestGetFileByPathFS(t *testing.T) {
+	fsys := testFS(t)
+
+	b, err := utils.GetFileByPathFS(fsys, "/templates/index.html")
+	if err != nil || string(b) != "<!DOCTYPE html><html></html>" {
+		t.Errorf("got %q, %v", b, err)
+	}
+
+	if _, err := utils.GetFileByPathFS(fsys, "missing.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("got error %v, want %v", err, fs.ErrNotExist)
+	}
+}
+
+func TestGetFileSizeFS(t *testing.T) {
+	fsys := testFS(t)
+
+	if size, err := utils.GetFileSizeFS(fsys, "static/app.css"); err != nil || size != 19 {
+		t.Errorf("got %d, %v", size, err)
+	}
+	if size, err := utils.GetFileSizeFS(fsys, "static/empty.txt"); err != nil || size != 0 {
+		t.Errorf("got %d, %v", size, err)
+	}
+	if _, err := utils.GetFileSizeFS(fsys, "static"); !errors.Is(err, utils.ErrNotRegularFile) {
+		t.Errorf("got error %v, want %v", err, utils.ErrNotRegularFile)
+	}
+	if _, err := utils.GetFileSizeFS(fsys, "missing.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("got error %v, want %v", err, fs.ErrNotExist)
+	}
+}
+
+func TestGetFileContentTypeFS(t *testing.T) {
+	fsys := testFS(t)
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"static/logo.png", "image/png"},
+		{"static/icon.svg", "image/svg+xml"},
+		{"templates/index.html", "text/html"},
+		{"static/app.css", "text/plain"},
+		{"static/blob.bin", "application/octet-stream"},
+		// Empty files are detected by the extension.
+		{"static/empty.txt", "text/plain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := utils.GetFileContentTypeFS(fsys, tt.name)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("got %q, want %q", got, tt.expected)
+			}
+		})
+	}
+
+	if _, err := utils.GetFileContentTypeFS(fsys, "missing.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("got error %v, want %v", err, fs.ErrNotExist)
+	}
+}
+
+func TestWalkFilesFS(t *testing.T) {
+	fsys := testFS(t)
+
+	walk := func(root string, types ...string) []string {
+		var names []string
+		err := utils.WalkFilesFS(fsys, root, func(name, contentType string, d fs.DirEntry) error {
+			names = append(names, name+" "+contentType)
+			return nil
+		}, types...)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	tests := []struct {
+		name     string
+		root     string
+		types    []string
+		expected []string
+	}{
+		{"exact type", "static", []string{"image/png"}, []string{
+			"static/icons/star.png image/png",
+			"static/logo.png image/png",
+		}},
+		{"type family", "/", []string{"image/*"}, []string{
+			"static/icon.svg image/svg+xml",
+			"static/icons/star.png image/png",
+			"static/logo.png image/png",
+		}},
+		{"several types", ".", []string{"text/html", "application/octet-stream"}, []string{
+			"static/blob.bin application/octet-stream",
+			"templates/index.html text/html",
+		}},
+		{"any type", "templates", nil, []string{"templates/index.html text/html"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := walk(tt.root, tt.types...)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("got %q, want %q", got, tt.expected)
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Errorf("got %q, want %q", got, tt.expected)
+					break
+				}
+			}
+		})
+	}
+
+	t.Run("skip directory", func(t *testing.T) {
+		count := 0
+		err := utils.WalkFilesFS(fsys, "static", func(name, contentType string, d fs.DirEntry) error {
+			count++
+			return fs.SkipDir
+		})
+		if err != nil || count != 1 {
+			t.Errorf("got %d calls, %v", count, err)
+		}
+	})
+
+	t.Run("callback error", func(t *testing.T) {
+		errStop := errors.New("stop")
+		err := utils.WalkFilesFS(fsys, ".", func(name, contentType string, d fs.DirEntry) error {
+			return errStop
+		})
+		if !errors.Is(err, errStop) {
+			t.Errorf("got error %v, want %v", err, errStop)
+		}
+	})
+
+	t.Run("missing root", func(t *testing.T) {
+		err := utils.WalkFilesFS(fsys, "missing", func(name, contentType string, d fs.DirEntry) error {
+			return nil
+		})
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("got error %v, want %v", err, fs.ErrNotExist)
+		}
+	})
+}
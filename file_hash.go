@@ -0,0 +1,231 @@
+package utils
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// HashAlgorithm is a digest algorithm supported by HashingReader.
+type HashAlgorithm string
+
+// Supported hash algorithms
+const (
+	HashMD5    HashAlgorithm = "md5"
+	HashSHA1   HashAlgorithm = "sha1"
+	HashSHA256 HashAlgorithm = "sha256"
+	HashCRC32C HashAlgorithm = "crc32c"
+	// HashS3ETag is the ETag S3 assigns to an object uploaded in DefaultMinPartSize parts,
+	// see MultipartETag. Use S3ETagHash for other part sizes.
+	HashS3ETag HashAlgorithm = "s3-etag"
+)
+
+// s3ETagHashPrefix prefixes the part size of S3ETagHash algorithms.
+const s3ETagHashPrefix = "s3-etag-"
+
+// DefaultHashAlgorithms is used when no algorithms are passed to the hashing helpers.
+var DefaultHashAlgorithms = []HashAlgorithm{HashMD5, HashSHA256}
+
+// crc32cTable is the Castagnoli polynomial table used by CRC32C.
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+type (
+	// Checksums maps the hash algorithm to the hex encoded digest.
+	Checksums map[HashAlgorithm]string
+
+	// HashingReader computes digests of the data read through it, similar to io.TeeReader.
+	// The digests are available after the stream is consumed, e.g. by an upload client.
+	HashingReader struct {
+		r        io.Reader
+		n        int64
+		digests  map[HashAlgorithm]digester
+		combined io.Writer
+	}
+
+	// digester is a running digest with a string representation.
+	digester interface {
+		io.Writer
+		digest() string
+	}
+
+	hashDigester struct {
+		hash.Hash
+	}
+
+	// s3ETagDigester computes the MD5 digest of every part and combines them into the multipart ETag.
+	s3ETagDigester struct {
+		partSize int64
+		written  int64
+		part     hash.Hash
+		sums     [][]byte
+	}
+)
+
+// NewHashingReader returns a reader which computes the given digests of the data read from r.
+// DefaultHashAlgorithms are used if no algorithm is passed.
+func NewHashingReader(r io.Reader, algs ...HashAlgorithm) (*HashingReader, error) {
+	if r == nil {
+		return nil, fmt.Errorf("invalid reader: %w", ErrInvalidReader)
+	}
+	if len(algs) == 0 {
+		algs = DefaultHashAlgorithms
+	}
+
+	h := &HashingReader{r: r, digests: make(map[HashAlgorithm]digester, len(algs))}
+	writers := make([]io.Writer, 0, len(algs))
+	for _, alg := range algs {
+		if _, ok := h.digests[alg]; ok {
+			continue
+		}
+		d, err := newDigester(alg)
+		if err != nil {
+			return nil, err
+		}
+		h.digests[alg] = d
+		writers = append(writers, d)
+	}
+	h.combined = io.MultiWriter(writers...)
+
+	return h, nil
+}
+
+// Read implements io.Reader.
+func (h *HashingReader) Read(p []byte) (int, error) {
+	n, err := h.r.Read(p)
+	if n > 0 {
+		// Hash writers never return an error.
+		_, _ = h.combined.Write(p[:n])
+		h.n += int64(n)
+	}
+	return n, err
+}
+
+// Size returns the number of bytes read so far.
+func (h *HashingReader) Size() int64 {
+	return h.n
+}
+
+// Checksums returns the digests of the data read so far.
+func (h *HashingReader) Checksums() Checksums {
+	sums := make(Checksums, len(h.digests))
+	for alg, d := range h.digests {
+		sums[alg] = d.digest()
+	}
+	return sums
+}
+
+// HashReader reads r to the end and returns the given digests computed in a single pass.
+// DefaultHashAlgorithms are used if no algorithm is passed.
+func HashReader(r io.Reader, algs ...HashAlgorithm) (Checksums, error) {
+	h, err := NewHashingReader(r, algs...)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := io.Copy(io.Discard, h); err != nil {
+		return nil, fmt.Errorf("failed to read data: %w", err)
+	}
+
+	return h.Checksums(), nil
+}
+
+// HashFile returns the given digests of the file at the given path.
+// DefaultHashAlgorithms are used if no algorithm is passed.
+func HashFile(path string, algs ...HashAlgorithm) (Checksums, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	return HashReader(f, algs...)
+}
+
+// MultipartETag returns the ETag S3 assigns to an object uploaded in parts with the given
+// raw MD5 digests: the hex encoded MD5 of the concatenated part digests followed by
+// a dash and the number of parts. A single digest is returned hex encoded as is,
+// which matches an object uploaded in a single request.
+func MultipartETag(partSums [][]byte) string {
+	if len(partSums) == 1 {
+		return hex.EncodeToString(partSums[0])
+	}
+
+	h := md5.New()
+	for _, sum := range partSums {
+		h.Write(sum)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)) + "-" + strconv.Itoa(len(partSums))
+}
+
+// S3ETagHash returns the algorithm computing the ETag S3 assigns to an object
+// uploaded in parts of the given size, e.g. FilePartPlan.PartSize.
+// The AWS CLI uses 8 MiB parts by default. HashS3ETag is returned if the size isn't positive.
+func S3ETagHash(partSize int64) HashAlgorithm {
+	if partSize <= 0 {
+		return HashS3ETag
+	}
+	return HashAlgorithm(s3ETagHashPrefix + strconv.FormatInt(partSize, 10))
+}
+
+// newDigester returns a digester of the given algorithm.
+func newDigester(alg HashAlgorithm) (digester, error) {
+	switch alg {
+	case HashMD5:
+		return hashDigester{md5.New()}, nil
+	case HashSHA1:
+		return hashDigester{sha1.New()}, nil
+	case HashSHA256:
+		return hashDigester{sha256.New()}, nil
+	case HashCRC32C:
+		return hashDigester{crc32.New(crc32cTable)}, nil
+	case HashS3ETag:
+		return &s3ETagDigester{partSize: DefaultMinPartSize, part: md5.New()}, nil
+	}
+
+	if strings.HasPrefix(string(alg), s3ETagHashPrefix) {
+		size := strings.TrimPrefix(string(alg), s3ETagHashPrefix)
+		if partSize, err := strconv.ParseInt(size, 10, 64); err == nil && partSize > 0 {
+			return &s3ETagDigester{partSize: partSize, part: md5.New()}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedHash, alg)
+}
+
+func (d hashDigester) digest() string {
+	return hex.EncodeToString(d.Sum(nil))
+}
+
+func (d *s3ETagDigester) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		if d.written == d.partSize {
+			d.sums = append(d.sums, d.part.Sum(nil))
+			d.part.Reset()
+			d.written = 0
+		}
+
+		chunk := p
+		if rest := d.partSize - d.written; int64(len(chunk)) > rest {
+			chunk = chunk[:rest]
+		}
+		d.part.Write(chunk)
+		d.written += int64(len(chunk))
+		p = p[len(chunk):]
+	}
+	return n, nil
+}
+
+func (d *s3ETagDigester) digest() string {
+	sums := append(d.sums[:len(d.sums):len(d.sums)], d.part.Sum(nil))
+	return MultipartETag(sums)
+}
@@ -0,0 +1,164 @@
+package utils_test
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dmitrymomot/go-utils"
+)
+
+func TestHashReader(t *testing.T) {
+	t.Run("all digests", func(t *testing.T) {
+		sums, err := utils.HashReader(strings.NewReader("123456789"),
+			utils.HashMD5, utils.HashSHA1, utils.HashSHA256, utils.HashCRC32C, utils.HashS3ETag)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := utils.Checksums{
+			utils.HashMD5:    "25f9e794323b453885f5181f1b624d0b",
+			utils.HashSHA1:   "f7c3bc1d808e04732adf679965ccc34ca7ae3441",
+			utils.HashSHA256: "15e2b0d3c33891ebb0f1ef609ec419420c20e320ce94c65fbc8c3312448eb225",
+			utils.HashCRC32C: "e3069283",
+			utils.HashS3ETag: "25f9e794323b453885f5181f1b624d0b",
+		}
+		for alg, want := range expected {
+			if sums[alg] != want {
+				t.Errorf("%s: got %s, want %s", alg, sums[alg], want)
+			}
+		}
+	})
+
+	t.Run("default algorithms", func(t *testing.T) {
+		sums, err := utils.HashReader(strings.NewReader("hello"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(sums) != 2 || sums[utils.HashMD5] != "5d41402abc4b2a76b9719d911017c592" ||
+			sums[utils.HashSHA256] != "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" {
+			t.Errorf("unexpected checksums: %v", sums)
+		}
+	})
+
+	t.Run("multipart etag", func(t *testing.T) {
+		data := bytes.Repeat([]byte("abcdefgh"), int(2*utils.DefaultMinPartSize/8)+100)
+		sums, err := utils.HashReader(bytes.NewReader(data), utils.HashS3ETag)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var parts [][]byte
+		for off := int64(0); off < int64(len(data)); off += utils.DefaultMinPartSize {
+			end := off + utils.DefaultMinPartSize
+			if end > int64(len(data)) {
+				end = int64(len(data))
+			}
+			sum := md5.Sum(data[off:end])
+			parts = append(parts, sum[:])
+		}
+
+		want := utils.MultipartETag(parts)
+		if !strings.HasSuffix(want, "-3") || sums[utils.HashS3ETag] != want {
+			t.Errorf("got %s, want %s", sums[utils.HashS3ETag], want)
+		}
+	})
+
+	t.Run("multipart etag with plan part size", func(t *testing.T) {
+		data := bytes.Repeat([]byte("0123456789"), 10)
+		plan, err := utils.PlanFileParts(int64(len(data)), utils.WithMinPartSize(16), utils.WithMaxPartSize(16))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		alg := utils.S3ETagHash(plan.PartSize)
+		sums, err := utils.HashReader(bytes.NewReader(data), alg)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var parts [][]byte
+		for _, p := range plan.Parts {
+			sum := md5.Sum(data[p.Offset : p.Offset+p.Length])
+			parts = append(parts, sum[:])
+		}
+		if want := utils.MultipartETag(parts); sums[alg] != want {
+			t.Errorf("got %s, want %s", sums[alg], want)
+		}
+
+		if utils.S3ETagHash(0) != utils.HashS3ETag {
+			t.Errorf("got %s, want %s", utils.S3ETagHash(0), utils.HashS3ETag)
+		}
+		if _, err := utils.HashReader(strings.NewReader("x"), "s3-etag-0"); !errors.Is(err, utils.ErrUnsupportedHash) {
+			t.Errorf("got error %v, want %v", err, utils.ErrUnsupportedHash)
+		}
+	})
+
+	t.Run("unsupported algorithm", func(t *testing.T) {
+		if _, err := utils.HashReader(strings.NewReader("x"), "whirlpool"); !errors.Is(err, utils.ErrUnsupportedHash) {
+			t.Errorf("got error %v, want %v", err, utils.ErrUnsupportedHash)
+		}
+		if _, err := utils.HashReader(nil); !errors.Is(err, utils.ErrInvalidReader) {
+			t.Errorf("got error %v, want %v", err, utils.ErrInvalidReader)
+		}
+	})
+}
+
+func TestHashingReader(t *testing.T) {
+	h, err := utils.NewHashingReader(strings.NewReader("hello"), utils.HashMD5, utils.HashMD5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Another consumer reads the stream.
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, h); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if buf.String() != "hello" || h.Size() != 5 {
+		t.Errorf("got %q of %d bytes", buf.String(), h.Size())
+	}
+	if sums := h.Checksums(); len(sums) != 1 || sums[utils.HashMD5] != "5d41402abc4b2a76b9719d911017c592" {
+		t.Errorf("unexpected checksums: %v", sums)
+	}
+}
+
+func TestHashFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(path, []byte("hello"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	sums, err := utils.HashFile(path, utils.HashSHA1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sums[utils.HashSHA1] != "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d" {
+		t.Errorf("got %s", sums[utils.HashSHA1])
+	}
+
+	if _, err := utils.HashFile(filepath.Join(t.TempDir(), "missing")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("got error %v, want %v", err, os.ErrNotExist)
+	}
+}
+
+func TestMultipartETag(t *testing.T) {
+	a, b := md5.Sum([]byte("a")), md5.Sum([]byte("b"))
+
+	if got := utils.MultipartETag([][]byte{a[:]}); got != hex.EncodeToString(a[:]) {
+		t.Errorf("got %s for a single part", got)
+	}
+
+	combined := md5.Sum(append(a[:], b[:]...))
+	want := hex.EncodeToString(combined[:]) + "-2"
+	if got := utils.MultipartETag([][]byte{a[:], b[:]}); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
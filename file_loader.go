@@ -0,0 +1,286 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+)
+
+type (
+	// FileLoader loads a file by its URI, e.g. "https://example.com/file.png" or "s3://bucket/key".
+	FileLoader interface {
+		Load(ctx context.Context, uri string) ([]byte, error)
+	}
+
+	// FileOpener is an optional interface of a FileLoader which can stream files
+	// instead of loading them into memory, see OpenFileByPath.
+	FileOpener interface {
+		Open(ctx context.Context, uri string) (*FileStream, error)
+	}
+
+	// FileLoaderFunc is an adapter to use ordinary functions as file loaders.
+	FileLoaderFunc func(ctx context.Context, uri string) ([]byte, error)
+
+	// Default file loaders, they implement both FileLoader and FileOpener.
+	httpFileLoader     struct{}
+	localFileURILoader struct{}
+	dataURILoader      struct{}
+	fsFileLoader       struct{ fsys fs.FS }
+)
+
+// Load calls f(ctx, uri).
+func (f FileLoaderFunc) Load(ctx context.Context, uri string) ([]byte, error) {
+	return f(ctx, uri)
+}
+
+// Registered file loaders by URI scheme
+var (
+	fileLoadersMu sync.RWMutex
+	fileLoaders   = map[string]FileLoader{
+		"http":  httpFileLoader{},
+		"https": httpFileLoader{},
+		"file":  localFileURILoader{},
+		"data":  dataURILoader{},
+	}
+)
+
+// RegisterFileLoader registers the file loader for the given URI scheme, e.g. "s3".
+// If a loader for the scheme already exists, it will be replaced.
+// A nil loader removes the scheme.
+func RegisterFileLoader(scheme string, loader FileLoader) {
+	scheme = strings.ToLower(strings.TrimSuffix(scheme, "://"))
+	if scheme == "" {
+		return
+	}
+
+	fileLoadersMu.Lock()
+	defer fileLoadersMu.Unlock()
+
+	if loader == nil {
+		delete(fileLoaders, scheme)
+		return
+	}
+	fileLoaders[scheme] = loader
+}
+
+// NewFSFileLoader returns a file loader which reads files from the given file system,
+// e.g. embed.FS. The URI path without the scheme is used as the file name:
+// with RegisterFileLoader("assets", NewFSFileLoader(assets)),
+// "assets://images/logo.png" is read as "images/logo.png".
+func NewFSFileLoader(fsys fs.FS) FileLoader {
+	return fsFileLoader{fsys: fsys}
+}
+
+// GetFileByPathWithContext returns the file bytes from the given path.
+// If the path is a URI with a registered scheme, the file is loaded by the scheme loader
+// (see RegisterFileLoader). http(s), file and data URIs are supported by default.
+// Otherwise the path is read from the local disk.
+func GetFileByPathWithContext(ctx context.Context, path string) ([]byte, error) {
+	if path == "" {
+		return nil, fmt.Errorf("path cannot be empty")
+	}
+
+	loader, err := fileLoaderByPath(path)
+	if err != nil {
+		return nil, err
+	}
+	if loader != nil {
+		return loader.Load(ctx, path)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file from local disk: %w", err)
+	}
+
+	return b, nil
+}
+
+// fileLoaderByPath returns the loader registered for the path scheme,
+// or nil if the path is a local file path.
+func fileLoaderByPath(path string) (FileLoader, error) {
+	scheme := uriScheme(path)
+	if scheme == "" {
+		return nil, nil
+	}
+
+	fileLoadersMu.RLock()
+	loader, ok := fileLoaders[scheme]
+	fileLoadersMu.RUnlock()
+
+	if ok {
+		return loader, nil
+	}
+	if strings.Contains(path, "://") {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedScheme, path)
+	}
+
+	return nil, nil
+}
+
+// uriScheme returns the lowercased URI scheme of the path, or an empty string.
+// Single letter schemes are ignored, so Windows paths like "C:\file.txt" are not treated as URIs.
+func uriScheme(path string) string {
+	i := strings.Index(path, ":")
+	if i < 2 {
+		return ""
+	}
+
+	for j, r := range path[:i] {
+		switch {
+		case (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z'):
+		case j > 0 && ((r >= '0' && r <= '9') || r == '+' || r == '-' || r == '.'):
+		default:
+			return ""
+		}
+	}
+
+	return strings.ToLower(path[:i])
+}
+
+// Load downloads the file from the http(s) URL.
+func (httpFileLoader) Load(ctx context.Context, uri string) ([]byte, error) {
+	b, err := DownloadFileWithContext(ctx, uri)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file from url: %w", err)
+	}
+	return b, nil
+}
+
+// Open streams the file from the http(s) URL.
+func (httpFileLoader) Open(ctx context.Context, uri string) (*FileStream, error) {
+	s, err := OpenDownload(ctx, uri)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file from url: %w", err)
+	}
+	return s, nil
+}
+
+// Load reads the file from the "file://" URI.
+func (l localFileURILoader) Load(ctx context.Context, uri string) ([]byte, error) {
+	name, err := l.path(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file from local disk: %w", err)
+	}
+
+	return b, nil
+}
+
+// Open opens the file from the "file://" URI.
+func (l localFileURILoader) Open(ctx context.Context, uri string) (*FileStream, error) {
+	name, err := l.path(uri)
+	if err != nil {
+		return nil, err
+	}
+	return openLocalFile(name)
+}
+
+// path returns the local file path of the "file://" URI.
+func (localFileURILoader) path(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrInvalidURL, uri)
+	}
+	if u.Host != "" && u.Host != "localhost" {
+		return "", fmt.Errorf("%w: remote host is not supported: %s", ErrInvalidURL, uri)
+	}
+	return u.Path, nil
+}
+
+// Load decodes the "data:" URI.
+func (dataURILoader) Load(ctx context.Context, uri string) ([]byte, error) {
+	_, b, err := parseDataURI(uri)
+	return b, err
+}
+
+// Open decodes the "data:" URI and returns a stream over the decoded data.
+func (dataURILoader) Open(ctx context.Context, uri string) (*FileStream, error) {
+	mediaType, b, err := parseDataURI(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	return newFileStream(io.NopCloser(bytes.NewReader(b)), int64(len(b)), mediaType, ""), nil
+}
+
+// Load reads the file from the file system.
+func (l fsFileLoader) Load(ctx context.Context, uri string) ([]byte, error) {
+	return GetFileByPathFS(l.fsys, l.name(uri))
+}
+
+// Open opens the file from the file system.
+func (l fsFileLoader) Open(ctx context.Context, uri string) (*FileStream, error) {
+	name := l.name(uri)
+
+	f, err := l.fsys.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file from file system: %w", err)
+	}
+
+	size := int64(-1)
+	if info, err := f.Stat(); err == nil {
+		size = info.Size()
+	}
+
+	return newFileStream(f, size, "", path.Base(name)), nil
+}
+
+// name returns the file name in the file system.
+func (fsFileLoader) name(uri string) string {
+	if i := strings.Index(uri, "://"); i >= 0 {
+		uri = uri[i+3:]
+	}
+	return strings.TrimPrefix(uri, "/")
+}
+
+// parseDataURI returns the media type and decoded data of the
+// "data:[<mediatype>][;base64],<data>" URI (RFC 2397).
+func parseDataURI(uri string) (string, []byte, error) {
+	comma := strings.Index(uri, ",")
+	if comma < 0 || !strings.HasPrefix(strings.ToLower(uri), "data:") {
+		return "", nil, fmt.Errorf("%w: malformed data uri", ErrInvalidURL)
+	}
+
+	meta, data := uri[len("data:"):comma], uri[comma+1:]
+
+	isBase64 := strings.HasSuffix(strings.ToLower(meta), ";base64")
+	if isBase64 {
+		meta = meta[:len(meta)-len(";base64")]
+	}
+
+	mediaType := strings.TrimSpace(meta)
+	if mediaType == "" || strings.HasPrefix(mediaType, ";") {
+		mediaType = "text/plain" + mediaType
+	}
+
+	data, err := url.PathUnescape(data)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %s", ErrInvalidURL, err.Error())
+	}
+	if !isBase64 {
+		return mediaType, []byte(data), nil
+	}
+
+	data = strings.TrimRight(data, "=")
+	b, err := base64.RawStdEncoding.DecodeString(data)
+	if err != nil {
+		if b, err = base64.RawURLEncoding.DecodeString(data); err != nil {
+			return "", nil, fmt.Errorf("%w: failed to decode base64 data: %s", ErrInvalidURL, err.Error())
+		}
+	}
+
+	return mediaType, b, nil
+}
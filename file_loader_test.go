@@ -0,0 +1,92 @@
+package utils_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/dmitrymomot/go-utils"
+)
+
+func TestGetFileByPathWithContext(t *testing.T) {
+	abs, err := filepath.Abs("./testdata/testfile.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr error
+	}{
+		{"local path", "./testdata/testfile.txt", "test file content", nil},
+		{"file uri", "file://" + filepath.ToSlash(abs), "test file content", nil},
+		{"data uri", "data:,Hello%2C%20World%21", "Hello, World!", nil},
+		{"base64 data uri", "data:text/plain;base64,SGVsbG8sIFdvcmxkIQ==", "Hello, World!", nil},
+		{"unpadded base64 data uri", "data:text/plain;base64,SGVsbG8sIFdvcmxkIQ", "Hello, World!", nil},
+		{"malformed data uri", "data:text/plain;base64", "", utils.ErrInvalidURL},
+		{"remote file uri", "file://example.com/etc/passwd", "", utils.ErrInvalidURL},
+		{"unsupported scheme", "ftp://example.com/file.txt", "", utils.ErrUnsupportedScheme},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := utils.GetFileByPathWithContext(context.Background(), tt.path)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetFileByPathWithContext() error = %v, want %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("GetFileByPathWithContext() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterFileLoader(t *testing.T) {
+	t.Run("custom scheme", func(t *testing.T) {
+		utils.RegisterFileLoader("mem", utils.FileLoaderFunc(func(ctx context.Context, uri string) ([]byte, error) {
+			return []byte(strings.TrimPrefix(uri, "mem://")), nil
+		}))
+		defer utils.RegisterFileLoader("mem", nil)
+
+		got, err := utils.GetFileByPath("mem://hello")
+		if err != nil {
+			t.Fatalf("GetFileByPath() error = %v", err)
+		}
+		if string(got) != "hello" {
+			t.Errorf("GetFileByPath() = %q, want %q", got, "hello")
+		}
+	})
+
+	t.Run("fs loader", func(t *testing.T) {
+		fsys := fstest.MapFS{"images/logo.svg": {Data: []byte("<svg/>")}}
+		utils.RegisterFileLoader("assets", utils.NewFSFileLoader(fsys))
+		defer utils.RegisterFileLoader("assets", nil)
+
+		got, err := utils.GetFileByPath("assets://images/logo.svg")
+		if err != nil {
+			t.Fatalf("GetFileByPath() error = %v", err)
+		}
+		if string(got) != "<svg/>" {
+			t.Errorf("GetFileByPath() = %q, want %q", got, "<svg/>")
+		}
+
+		if _, err := utils.GetFileByPath("assets://missing.svg"); err == nil {
+			t.Error("expected error but got none")
+		}
+	})
+
+	t.Run("unregistered scheme", func(t *testing.T) {
+		utils.RegisterFileLoader("tmp", utils.FileLoaderFunc(func(ctx context.Context, uri string) ([]byte, error) {
+			return nil, nil
+		}))
+		utils.RegisterFileLoader("tmp", nil)
+
+		if _, err := utils.GetFileByPath("tmp://file"); !errors.Is(err, utils.ErrUnsupportedScheme) {
+			t.Errorf("expected error %v, got %v", utils.ErrUnsupportedScheme, err)
+		}
+	})
+}
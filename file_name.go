@@ -0,0 +1,267 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Default file name settings.
+const (
+	// DefaultMaxFileNameLength is the max file name length in bytes supported by most file systems.
+	DefaultMaxFileNameLength = 255
+	// DefaultFileName is used when nothing is left of the name after sanitization.
+	DefaultFileName = "file"
+	// maxUniqueFileNameAttempts limits the number of candidates checked by UniqueFileName.
+	maxUniqueFileNameAttempts = 10000
+)
+
+// fileNameReservedChars are not allowed in file names on Windows, "/" is the path separator on Unix.
+const fileNameReservedChars = `<>:"/\|?*`
+
+// windowsReservedNames are device names which can't be used as file names on Windows, with any extension.
+var windowsReservedNames = map[string]struct{}{
+	"CON": {}, "PRN": {}, "AUX": {}, "NUL": {},
+	"COM1": {}, "COM2": {}, "COM3": {}, "COM4": {}, "COM5": {}, "COM6": {}, "COM7": {}, "COM8": {}, "COM9": {},
+	"LPT1": {}, "LPT2": {}, "LPT3": {}, "LPT4": {}, "LPT5": {}, "LPT6": {}, "LPT7": {}, "LPT8": {}, "LPT9": {},
+}
+
+type (
+	// FileNameOption configures the file name helpers.
+	FileNameOption func(*fileNameOptions)
+
+	fileNameOptions struct {
+		maxLength    int
+		ascii        bool
+		replacement  string
+		randomSuffix bool
+	}
+)
+
+// WithMaxFileNameLength sets the max file name length in bytes, including the extension.
+// Defaults to DefaultMaxFileNameLength.
+func WithMaxFileNameLength(n int) FileNameOption {
+	return func(o *fileNameOptions) {
+		if n > 0 {
+			o.maxLength = n
+		}
+	}
+}
+
+// WithASCIIFileName transliterates the file name to ASCII: diacritics are removed
+// (e.g. "café" -> "cafe"), other non-ASCII characters are replaced.
+func WithASCIIFileName() FileNameOption {
+	return func(o *fileNameOptions) {
+		o.ascii = true
+	}
+}
+
+// WithFileNameReplacement sets the replacement of reserved characters. Defaults to "_".
+// The replacement itself is sanitized, so it can't introduce reserved characters.
+func WithFileNameReplacement(s string) FileNameOption {
+	return func(o *fileNameOptions) {
+		o.replacement = strings.Map(func(r rune) rune {
+			if isFileNameReservedRune(r) {
+				return -1
+			}
+			return r
+		}, s)
+	}
+}
+
+// WithRandomFileNameSuffix makes UniqueFileName add a random suffix, e.g. "photo-3f2a9c1b.jpg",
+// instead of a counter, e.g. "photo-1.jpg".
+func WithRandomFileNameSuffix() FileNameOption {
+	return func(o *fileNameOptions) {
+		o.randomSuffix = true
+	}
+}
+
+// newFileNameOptions returns file name options with defaults applied.
+func newFileNameOptions(opts ...FileNameOption) *fileNameOptions {
+	o := &fileNameOptions{
+		maxLength:   DefaultMaxFileNameLength,
+		replacement: "_",
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// SanitizeFileName returns a file name which is safe to use on any file system:
+// directories are stripped (so "../../etc/passwd" becomes "passwd"), control characters
+// are removed, reserved characters are replaced, Windows device names (CON, NUL, etc.) are
+// escaped, leading and trailing dots and spaces are trimmed and Unicode is NFC normalized.
+// The name is truncated to the max length keeping the extension. DefaultFileName is returned
+// if nothing is left of the name.
+func SanitizeFileName(name string, opts ...FileNameOption) string {
+	return newFileNameOptions(opts...).sanitize(name)
+}
+
+// UniqueFileName returns a sanitized file name which doesn't exist in the directory yet.
+// If the name is taken, a counter or a random suffix (see WithRandomFileNameSuffix) is added
+// before the extension. The name is reserved only when the file is created, so use
+// os.OpenFile with os.O_CREATE|os.O_EXCL to detect concurrent writers.
+func UniqueFileName(dir, name string, opts ...FileNameOption) (string, error) {
+	return newFileNameOptions(opts...).unique(name, func(candidate string) error {
+		_, err := os.Lstat(filepath.Join(dir, candidate))
+		return err
+	})
+}
+
+// UniqueFileNameFS is like UniqueFileName but checks the names in the directory of the file system.
+func UniqueFileNameFS(fsys fs.FS, dir, name string, opts ...FileNameOption) (string, error) {
+	if fsys == nil {
+		return "", fmt.Errorf("invalid file system: %w", ErrInvalidReader)
+	}
+
+	return newFileNameOptions(opts...).unique(name, func(candidate string) error {
+		_, err := fs.Stat(fsys, path.Join(dir, candidate))
+		return err
+	})
+}
+
+// sanitize implements SanitizeFileName.
+func (o *fileNameOptions) sanitize(name string) string {
+	name = norm.NFC.String(name)
+
+	// Strip directories, both Unix and Windows separators.
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+
+	if o.ascii {
+		name = transliterateASCII(name)
+	}
+
+	var sb strings.Builder
+	for _, r := range name {
+		switch {
+		case r == utf8.RuneError, unicode.IsControl(r), unicode.Is(unicode.Cf, r):
+			// Drop invalid bytes, control and invisible formatting characters (e.g. RTL override).
+		case isFileNameReservedRune(r), o.ascii && r >= utf8.RuneSelf:
+			sb.WriteString(o.replacement)
+		case unicode.IsSpace(r):
+			sb.WriteByte(' ')
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	name = trimFileName(sb.String())
+
+	base, ext := splitFileExt(name)
+	// Device names are reserved with any extension, e.g. "con.tar.gz".
+	stem, _, _ := strings.Cut(name, ".")
+	if _, ok := windowsReservedNames[strings.ToUpper(strings.TrimRight(stem, " "))]; ok {
+		prefix := o.replacement
+		if prefix == "" {
+			prefix = "_"
+		}
+		base = prefix + base
+	}
+	name = truncateFileName(base, ext, o.maxLength)
+
+	if name == "" {
+		return DefaultFileName
+	}
+	return name
+}
+
+// unique implements UniqueFileName, stat returns fs.ErrNotExist if the name is free.
+func (o *fileNameOptions) unique(name string, stat func(string) error) (string, error) {
+	name = o.sanitize(name)
+	base, ext := splitFileExt(name)
+
+	candidate := name
+	for i := 1; i <= maxUniqueFileNameAttempts; i++ {
+		err := stat(candidate)
+		if errors.Is(err, fs.ErrNotExist) {
+			return candidate, nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to check file name %q: %w", candidate, err)
+		}
+
+		suffix := "-" + strconv.Itoa(i)
+		if o.randomSuffix {
+			b := make([]byte, 4)
+			if _, err := rand.Read(b); err != nil {
+				return "", fmt.Errorf("failed to generate random suffix: %w", err)
+			}
+			suffix = "-" + hex.EncodeToString(b)
+		}
+		candidate = truncateFileName(base, suffix+ext, o.maxLength)
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrNoUniqueFileName, name)
+}
+
+// isFileNameReservedRune reports whether the character is not allowed in file names.
+func isFileNameReservedRune(r rune) bool {
+	return strings.ContainsRune(fileNameReservedChars, r) || unicode.IsControl(r)
+}
+
+// transliterateASCII removes diacritics, e.g. "é" -> "e".
+func transliterateASCII(s string) string {
+	t := transform.Chain(norm.NFKD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	result, _, err := transform.String(t, s)
+	if err != nil {
+		return s
+	}
+	return result
+}
+
+// trimFileName trims spaces and dots: leading dots make the file hidden,
+// trailing dots and spaces are stripped by Windows.
+func trimFileName(name string) string {
+	return strings.Trim(name, " .")
+}
+
+// splitFileExt splits the file name into the base name and the extension with a dot.
+func splitFileExt(name string) (string, string) {
+	ext := filepath.Ext(name)
+	if ext == name || strings.ContainsAny(ext, " ") {
+		return name, ""
+	}
+	return strings.TrimSuffix(name, ext), ext
+}
+
+// truncateFileName joins the base name and the suffix truncating the base name,
+// so the result is not longer than maxLength bytes. The suffix is truncated only
+// if it doesn't fit at all. Multibyte characters are never split.
+func truncateFileName(base, suffix string, maxLength int) string {
+	if len(suffix) >= maxLength {
+		base, suffix = base+suffix, ""
+	}
+
+	limit := maxLength - len(suffix)
+	if len(base) > limit {
+		cut := 0
+		for i := range base {
+			if i > limit {
+				break
+			}
+			cut = i
+		}
+		base = strings.TrimRight(base[:cut], " .")
+	}
+
+	if base == "" {
+		return trimFileName(suffix)
+	}
+	return base + suffix
+}
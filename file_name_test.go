@@ -0,0 +1,142 @@
+package utils_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"unicode/utf8"
+
+	"github.com/dmitrymomot/go-utils"
+)
+
+func TestSanitizeFileName(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		opts     []utils.FileNameOption
+		expected string
+	}{
+		{"plain name", "photo.jpg", nil, "photo.jpg"},
+		{"unix path traversal", "../../etc/passwd", nil, "passwd"},
+		{"windows path", `C:\Users\john\report.pdf`, nil, "report.pdf"},
+		{"control characters", "re\x00po\nrt\t.pdf", nil, "report.pdf"},
+		{"rtl override", "invoice\u202Efdp.exe", nil, "invoicefdp.exe"},
+		{"reserved characters", `what?<is>"this"|*.txt`, nil, "what__is__this___.txt"},
+		{"custom replacement", "a:b.txt", []utils.FileNameOption{utils.WithFileNameReplacement("-")}, "a-b.txt"},
+		{"reserved device name", "CON", nil, "_CON"},
+		{"reserved device name with extension", "nul.txt", nil, "_nul.txt"},
+		{"reserved device name with double extension", "con.tar.gz", nil, "_con.tar.gz"},
+		{"reserved device name with space", "Com1 .tar.gz", nil, "_Com1 .tar.gz"},
+		{"device name prefix", "console.tar.gz", nil, "console.tar.gz"},
+		{"not a device name", "console.txt", nil, "console.txt"},
+		{"leading and trailing dots", "..hidden.txt. ", nil, "hidden.txt"},
+		{"unicode whitespace", "my\u00a0file.txt", nil, "my file.txt"},
+		{"nfc normalization", "cafe\u0301.txt", nil, "caf\u00e9.txt"},
+		{"unicode kept", "отчёт.pdf", nil, "отчёт.pdf"},
+		{"ascii transliteration", "Crème brûlée.pdf", []utils.FileNameOption{utils.WithASCIIFileName()}, "Creme brulee.pdf"},
+		{"ascii replacement", "отчёт.pdf", []utils.FileNameOption{utils.WithASCIIFileName()}, "_____.pdf"},
+		{"empty", "", nil, utils.DefaultFileName},
+		{"only dots", "..", nil, utils.DefaultFileName},
+		{"directory only", "uploads/", nil, utils.DefaultFileName},
+		{"truncated keeping extension", strings.Repeat("a", 20) + ".jpeg", []utils.FileNameOption{utils.WithMaxFileNameLength(10)}, "aaaaa.jpeg"},
+		{"truncated multibyte", "ééééé.txt", []utils.FileNameOption{utils.WithMaxFileNameLength(8)}, "éé.txt"},
+		{"long extension", "a." + strings.Repeat("x", 20), []utils.FileNameOption{utils.WithMaxFileNameLength(10)}, "a.xxxxxxxx"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := utils.SanitizeFileName(tt.input, tt.opts...)
+			if got != tt.expected {
+				t.Errorf("got %q, want %q", got, tt.expected)
+			}
+		})
+	}
+
+	t.Run("default max length", func(t *testing.T) {
+		got := utils.SanitizeFileName(strings.Repeat("ж", 300) + ".txt")
+		if len(got) > utils.DefaultMaxFileNameLength || !strings.HasSuffix(got, ".txt") || !utf8.ValidString(got) {
+			t.Errorf("got invalid name of %d bytes: %q", len(got), got)
+		}
+	})
+}
+
+func TestUniqueFileName(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"photo.jpg", "photo-1.jpg", "notes"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"free name", "report.pdf", "report.pdf"},
+		{"counter", "photo.jpg", "photo-2.jpg"},
+		{"without extension", "notes", "notes-1"},
+		{"sanitized", "../photo.jpg", "photo-2.jpg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := utils.UniqueFileName(dir, tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("got %q, want %q", got, tt.expected)
+			}
+		})
+	}
+
+	t.Run("random suffix", func(t *testing.T) {
+		got, err := utils.UniqueFileName(dir, "photo.jpg", utils.WithRandomFileNameSuffix())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !regexp.MustCompile(`^photo-[0-9a-f]{8}\.jpg$`).MatchString(got) {
+			t.Errorf("unexpected name %q", got)
+		}
+	})
+
+	t.Run("suffix fits max length", func(t *testing.T) {
+		got, err := utils.UniqueFileName(dir, "photo.jpg", utils.WithMaxFileNameLength(9))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != "pho-1.jpg" {
+			t.Errorf("got %q, want %q", got, "pho-1.jpg")
+		}
+	})
+}
+
+func TestUniqueFileNameFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"uploads/avatar.png":   {},
+		"uploads/avatar-1.png": {},
+		"uploads/avatar-2.png": {},
+	}
+
+	got, err := utils.UniqueFileNameFS(fsys, "uploads", "avatar.png")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "avatar-3.png" {
+		t.Errorf("got %q, want %q", got, "avatar-3.png")
+	}
+
+	got, err = utils.UniqueFileNameFS(fsys, ".", "avatar.png")
+	if err != nil || got != "avatar.png" {
+		t.Errorf("got %q, %v", got, err)
+	}
+
+	if _, err := utils.UniqueFileNameFS(nil, ".", "a.txt"); !errors.Is(err, utils.ErrInvalidReader) {
+		t.Errorf("got error %v, want %v", err, utils.ErrInvalidReader)
+	}
+}
@@ -0,0 +1,169 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+)
+
+// Default multipart upload limits (Amazon S3).
+const (
+	DefaultMinPartSize int64 = 5 << 20 // 5 MiB
+	DefaultMaxPartSize int64 = 5 << 30 // 5 GiB
+	DefaultMaxParts          = 10000
+)
+
+type (
+	// FilePart is a single part of a multipart upload.
+	FilePart struct {
+		// Number is the 1-based part number.
+		Number int
+		// Offset is the position of the first byte of the part in the file.
+		Offset int64
+		// Length is the part size in bytes, the last part may be smaller than the part size.
+		Length int64
+	}
+
+	// FilePartPlan describes how a file is split into parts.
+	FilePartPlan struct {
+		// Size is the total file size in bytes.
+		Size int64
+		// PartSize is the size of every part except the last one.
+		PartSize int64
+		// Parts is the list of parts in upload order.
+		Parts []FilePart
+	}
+
+	// FilePartOption configures the part planner.
+	FilePartOption func(*filePartOptions)
+
+	filePartOptions struct {
+		minPartSize int64
+		maxPartSize int64
+		maxParts    int
+		partSize    int64
+	}
+)
+
+// WithMinPartSize sets the min part size, the last part may be smaller.
+func WithMinPartSize(size int64) FilePartOption {
+	return func(o *filePartOptions) {
+		o.minPartSize = size
+	}
+}
+
+// WithMaxPartSize sets the max part size.
+func WithMaxPartSize(size int64) FilePartOption {
+	return func(o *filePartOptions) {
+		o.maxPartSize = size
+	}
+}
+
+// WithMaxParts sets the max number of parts.
+func WithMaxParts(n int) FilePartOption {
+	return func(o *filePartOptions) {
+		o.maxParts = n
+	}
+}
+
+// WithPartSize sets the preferred part size.
+// It's increased automatically if the file doesn't fit into the max number of parts.
+func WithPartSize(size int64) FilePartOption {
+	return func(o *filePartOptions) {
+		o.partSize = size
+	}
+}
+
+// PlanFileParts splits a file of the given size into parts.
+// By default the S3 limits are used: parts from 5 MiB to 5 GiB, at most 10000 parts.
+// The part size is the smallest allowed size (or the preferred one, see WithPartSize)
+// which fits the file into the max number of parts.
+// An empty file is planned as a single empty part.
+func PlanFileParts(size int64, opts ...FilePartOption) (FilePartPlan, error) {
+	o := filePartOptions{
+		minPartSize: DefaultMinPartSize,
+		maxPartSize: DefaultMaxPartSize,
+		maxParts:    DefaultMaxParts,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if size < 0 {
+		return FilePartPlan{}, ErrInvalidFileSize
+	}
+	if o.minPartSize <= 0 || o.maxPartSize < o.minPartSize || o.partSize < 0 {
+		return FilePartPlan{}, ErrInvalidPartSize
+	}
+	if o.maxParts <= 0 {
+		return FilePartPlan{}, fmt.Errorf("%w: max parts must be greater than 0", ErrTooManyParts)
+	}
+
+	// The smallest part size which fits the file into the max number of parts.
+	minSize := (size + int64(o.maxParts) - 1) / int64(o.maxParts)
+	if minSize > o.maxPartSize {
+		return FilePartPlan{}, fmt.Errorf("%w: %d bytes, max %d parts of %d bytes",
+			ErrTooManyParts, size, o.maxParts, o.maxPartSize)
+	}
+
+	partSize := o.minPartSize
+	if o.partSize > partSize {
+		partSize = o.partSize
+	}
+	if minSize > partSize {
+		partSize = minSize
+	}
+	if partSize > o.maxPartSize {
+		partSize = o.maxPartSize
+	}
+
+	count := (size + partSize - 1) / partSize
+	if count == 0 {
+		count = 1
+	}
+
+	plan := FilePartPlan{
+		Size:     size,
+		PartSize: partSize,
+		Parts:    make([]FilePart, 0, count),
+	}
+	for i := int64(0); i < count; i++ {
+		offset := i * partSize
+		length := partSize
+		if rest := size - offset; rest < length {
+			length = rest
+		}
+		plan.Parts = append(plan.Parts, FilePart{
+			Number: int(i) + 1,
+			Offset: offset,
+			Length: length,
+		})
+	}
+
+	return plan, nil
+}
+
+// PlanFilePartsFromReader splits the file into parts, see PlanFileParts.
+// The read position of the file is reset to the beginning.
+func PlanFilePartsFromReader(file io.ReadSeeker, opts ...FilePartOption) (FilePartPlan, error) {
+	size, err := GetFileSize(file)
+	if err != nil {
+		return FilePartPlan{}, err
+	}
+
+	return PlanFileParts(size, opts...)
+}
+
+// Section returns a reader of the part content.
+func (p FilePart) Section(r io.ReaderAt) *io.SectionReader {
+	return io.NewSectionReader(r, p.Offset, p.Length)
+}
+
+// Sections returns a reader of every part content in upload order.
+// The readers are independent, so the parts can be read concurrently.
+func (p FilePartPlan) Sections(r io.ReaderAt) []*io.SectionReader {
+	sections := make([]*io.SectionReader, len(p.Parts))
+	for i, part := range p.Parts {
+		sections[i] = part.Section(r)
+	}
+	return sections
+}
@@ -0,0 +1,166 @@
+package utils_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/dmitrymomot/go-utils"
+)
+
+func TestPlanFileParts(t *testing.T) {
+	const mib = 1 << 20
+
+	tests := []struct {
+		name     string
+		size     int64
+		opts     []utils.FilePartOption
+		partSize int64
+		parts    int
+		lastLen  int64
+		err      error
+	}{
+		{
+			name:     "small file",
+			size:     mib,
+			partSize: utils.DefaultMinPartSize,
+			parts:    1,
+			lastLen:  mib,
+		},
+		{
+			name:     "empty file",
+			size:     0,
+			partSize: utils.DefaultMinPartSize,
+			parts:    1,
+			lastLen:  0,
+		},
+		{
+			name:     "exact multiple of min part size",
+			size:     3 * utils.DefaultMinPartSize,
+			partSize: utils.DefaultMinPartSize,
+			parts:    3,
+			lastLen:  utils.DefaultMinPartSize,
+		},
+		{
+			name:     "part size grows to fit max parts",
+			size:     100000 * mib,
+			partSize: 10 * mib,
+			parts:    10000,
+			lastLen:  10 * mib,
+		},
+		{
+			name:     "preferred part size",
+			size:     25 * mib,
+			opts:     []utils.FilePartOption{utils.WithPartSize(8 * mib)},
+			partSize: 8 * mib,
+			parts:    4,
+			lastLen:  mib,
+		},
+		{
+			name:     "preferred part size is clamped to max",
+			size:     25 * mib,
+			opts:     []utils.FilePartOption{utils.WithPartSize(100 * mib), utils.WithMaxPartSize(10 * mib)},
+			partSize: 10 * mib,
+			parts:    3,
+			lastLen:  5 * mib,
+		},
+		{
+			name:     "custom limits",
+			size:     10,
+			opts:     []utils.FilePartOption{utils.WithMinPartSize(1), utils.WithMaxPartSize(4), utils.WithMaxParts(3)},
+			partSize: 4,
+			parts:    3,
+			lastLen:  2,
+		},
+		{
+			name: "too many parts",
+			size: 13,
+			opts: []utils.FilePartOption{utils.WithMinPartSize(1), utils.WithMaxPartSize(4), utils.WithMaxParts(3)},
+			err:  utils.ErrTooManyParts,
+		},
+		{
+			name: "negative size",
+			size: -1,
+			err:  utils.ErrInvalidFileSize,
+		},
+		{
+			name: "invalid min part size",
+			size: 10,
+			opts: []utils.FilePartOption{utils.WithMinPartSize(0)},
+			err:  utils.ErrInvalidPartSize,
+		},
+		{
+			name: "max part size less than min",
+			size: 10,
+			opts: []utils.FilePartOption{utils.WithMinPartSize(10), utils.WithMaxPartSize(5)},
+			err:  utils.ErrInvalidPartSize,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := utils.PlanFileParts(tt.size, tt.opts...)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("got error %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if plan.PartSize != tt.partSize || len(plan.Parts) != tt.parts {
+				t.Fatalf("got %d parts of %d bytes, want %d parts of %d bytes", len(plan.Parts), plan.PartSize, tt.parts, tt.partSize)
+			}
+
+			var offset int64
+			for i, p := range plan.Parts {
+				if p.Number != i+1 || p.Offset != offset {
+					t.Errorf("unexpected part %d: %+v", i, p)
+				}
+				offset += p.Length
+			}
+			if offset != tt.size {
+				t.Errorf("parts cover %d bytes, want %d", offset, tt.size)
+			}
+			if last := plan.Parts[len(plan.Parts)-1]; last.Length != tt.lastLen {
+				t.Errorf("got last part length %d, want %d", last.Length, tt.lastLen)
+			}
+		})
+	}
+}
+
+func TestPlanFilePartsFromReader(t *testing.T) {
+	content := "The quick brown fox jumps over the lazy dog"
+	r := strings.NewReader(content)
+	_, _ = r.Seek(10, io.SeekStart)
+
+	plan, err := utils.PlanFilePartsFromReader(r, utils.WithMinPartSize(10), utils.WithMaxPartSize(10))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if plan.Size != int64(len(content)) || len(plan.Parts) != 5 {
+		t.Fatalf("unexpected plan: %+v", plan)
+	}
+
+	var sb strings.Builder
+	for _, s := range plan.Sections(r) {
+		if _, err := io.Copy(&sb, s); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if sb.String() != content {
+		t.Errorf("got %q, want %q", sb.String(), content)
+	}
+
+	data, _ := io.ReadAll(plan.Parts[4].Section(r))
+	if string(data) != "dog" {
+		t.Errorf("got last part %q, want %q", data, "dog")
+	}
+
+	if _, err := utils.PlanFilePartsFromReader(nil); !errors.Is(err, utils.ErrInvalidReader) {
+		t.Errorf("got error %v, want %v", err, utils.ErrInvalidReader)
+	}
+}
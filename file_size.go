@@ -0,0 +1,255 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// Decimal file size units
+const (
+	KB int64 = 1000
+	MB       = KB * 1000
+	GB       = MB * 1000
+	TB       = GB * 1000
+	PB       = TB * 1000
+	EB       = PB * 1000
+)
+
+// Binary file size units
+const (
+	KiB int64 = 1 << (10 * (iota + 1))
+	MiB
+	GiB
+	TiB
+	PiB
+	EiB
+)
+
+// DefaultFileSizePrecision is the default max number of fraction digits used by FormatFileSize.
+const DefaultFileSizePrecision = 1
+
+var (
+	siSizeUnits  = []string{"B", "kB", "MB", "GB", "TB", "PB", "EB"}
+	iecSizeUnits = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+
+	// sizeUnitPrefixes maps the lowercase unit prefix to its power.
+	sizeUnitPrefixes = map[string]int{"k": 1, "m": 2, "g": 3, "t": 4, "p": 5, "e": 6}
+
+	// commaDecimalLanguages use a comma as the decimal separator,
+	// except the regions listed in dotDecimalRegions.
+	commaDecimalLanguages = map[string]struct{}{
+		"af": {}, "az": {}, "be": {}, "bg": {}, "bs": {}, "ca": {}, "cs": {}, "da": {}, "de": {},
+		"el": {}, "es": {}, "et": {}, "eu": {}, "fi": {}, "fr": {}, "gl": {}, "hr": {}, "hu": {},
+		"hy": {}, "id": {}, "is": {}, "it": {}, "ka": {}, "kk": {}, "ky": {}, "lt": {}, "lv": {},
+		"mk": {}, "mn": {}, "nb": {}, "nl": {}, "nn": {}, "no": {}, "pl": {}, "pt": {}, "ro": {},
+		"ru": {}, "sk": {}, "sl": {}, "sq": {}, "sr": {}, "sv": {}, "tr": {}, "uk": {}, "uz": {},
+		"vi": {},
+	}
+	dotDecimalRegions = map[string]struct{}{
+		"de-CH": {}, "de-LI": {}, "it-CH": {}, "es-MX": {}, "es-US": {}, "es-419": {},
+	}
+)
+
+type (
+	// FileSizeOption configures FormatFileSize.
+	FileSizeOption func(*fileSizeOptions)
+
+	fileSizeOptions struct {
+		si        bool
+		precision int
+		separator string
+	}
+)
+
+// WithSIUnits formats the size in decimal units: kB, MB, GB, etc., where 1 kB is 1000 bytes.
+func WithSIUnits() FileSizeOption {
+	return func(o *fileSizeOptions) {
+		o.si = true
+	}
+}
+
+// WithIECUnits formats the size in binary units: KiB, MiB, GiB, etc., where 1 KiB is 1024 bytes.
+// It's the default.
+func WithIECUnits() FileSizeOption {
+	return func(o *fileSizeOptions) {
+		o.si = false
+	}
+}
+
+// WithFileSizePrecision sets the max number of fraction digits, trailing zeros are trimmed.
+// Defaults to DefaultFileSizePrecision.
+func WithFileSizePrecision(n int) FileSizeOption {
+	return func(o *fileSizeOptions) {
+		if n >= 0 {
+			o.precision = n
+		}
+	}
+}
+
+// WithDecimalSeparator sets the decimal separator. Defaults to ".".
+func WithDecimalSeparator(sep string) FileSizeOption {
+	return func(o *fileSizeOptions) {
+		if sep != "" {
+			o.separator = sep
+		}
+	}
+}
+
+// WithFileSizeLocale sets the decimal separator used in the given locale, e.g. "de-DE" or "fr".
+// Unknown locales are ignored.
+func WithFileSizeLocale(locale string) FileSizeOption {
+	return func(o *fileSizeOptions) {
+		tag, err := language.Parse(locale)
+		if err != nil {
+			return
+		}
+		o.separator = decimalSeparator(tag)
+	}
+}
+
+// FormatFileSize returns the human-readable file size, e.g. "1.5 MiB" or "1.6 MB" with WithSIUnits.
+// The value is rounded to the precision, sizes under 1 unit are formatted in bytes: "512 B".
+func FormatFileSize(size int64, opts ...FileSizeOption) string {
+	o := &fileSizeOptions{
+		precision: DefaultFileSizePrecision,
+		separator: ".",
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	base, units := float64(KiB), iecSizeUnits
+	if o.si {
+		base, units = float64(KB), siSizeUnits
+	}
+
+	sign := ""
+	value := float64(size)
+	if size < 0 {
+		sign, value = "-", -value
+	}
+
+	unit := 0
+	for value >= base && unit < len(units)-1 {
+		value /= base
+		unit++
+	}
+
+	num := strconv.FormatFloat(value, 'f', o.precision, 64)
+	// Rounding may reach the next unit, e.g. 1023.96 KiB is "1024.0 KiB".
+	if rounded, _ := strconv.ParseFloat(num, 64); rounded >= base && unit < len(units)-1 {
+		unit++
+		num = strconv.FormatFloat(rounded/base, 'f', o.precision, 64)
+	}
+	if unit == 0 {
+		num = strconv.FormatFloat(value, 'f', 0, 64)
+	}
+	if strings.Contains(num, ".") {
+		num = strings.TrimSuffix(TrimRightZeros(num), ".")
+	}
+
+	return sign + strings.Replace(num, ".", o.separator, 1) + " " + units[unit]
+}
+
+// ParseFileSize parses the human-readable file size to the number of bytes.
+// The number may have a fraction, the result is truncated to whole bytes.
+// The unit is case-insensitive and may be separated from the number by spaces:
+//   - IEC units are binary: "KiB", "MiB", ... "EiB", also "Ki", "Mi", etc.;
+//   - SI units are decimal: "kB", "MB", ... "EB", also bare prefixes "K", "M", ... "E";
+//   - "B" or no unit means bytes.
+//
+// Note that "10M" and "10MB" are 10000000 bytes, use "10MiB" for 10485760 bytes.
+func ParseFileSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return r != '.' && (r < '0' || r > '9')
+	})
+	if i < 0 {
+		i = len(s)
+	}
+
+	num, unit := s[:i], strings.ToLower(strings.TrimSpace(s[i:]))
+	if num == "" || strings.Count(num, ".") > 1 || strings.Trim(num, ".") == "" {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidFileSizeFormat, s)
+	}
+
+	mult, ok := fileSizeMultiplier(unit)
+	if !ok {
+		return 0, fmt.Errorf("%w: unknown unit %q", ErrInvalidFileSizeFormat, s[i:])
+	}
+
+	whole, frac, _ := strings.Cut(num, ".")
+	var size uint64
+	if whole != "" {
+		n, err := strconv.ParseUint(whole, 10, 64)
+		if err != nil || n > math.MaxInt64/uint64(mult) {
+			return 0, fmt.Errorf("%w: %q", ErrFileSizeOverflow, s)
+		}
+		size = n * uint64(mult)
+	}
+	if frac != "" {
+		// The fraction is computed exactly, floats would turn "0.29 kB" into 289 bytes.
+		n, ok := new(big.Int).SetString(frac, 10)
+		if !ok {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidFileSizeFormat, s)
+		}
+		n.Mul(n, big.NewInt(mult))
+		n.Quo(n, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(len(frac))), nil))
+		size += n.Uint64()
+	}
+	if size > math.MaxInt64 {
+		return 0, fmt.Errorf("%w: %q", ErrFileSizeOverflow, s)
+	}
+
+	return int64(size), nil
+}
+
+// fileSizeMultiplier returns the number of bytes in the lowercase unit.
+func fileSizeMultiplier(unit string) (int64, bool) {
+	if unit == "" || unit == "b" {
+		return 1, true
+	}
+
+	binary := false
+	switch {
+	case strings.HasSuffix(unit, "ib"):
+		unit, binary = strings.TrimSuffix(unit, "ib"), true
+	case strings.HasSuffix(unit, "i"):
+		unit, binary = strings.TrimSuffix(unit, "i"), true
+	case strings.HasSuffix(unit, "b"):
+		unit = strings.TrimSuffix(unit, "b")
+	}
+
+	power, ok := sizeUnitPrefixes[unit]
+	if !ok {
+		return 0, false
+	}
+
+	base := KB
+	if binary {
+		base = KiB
+	}
+	mult := int64(1)
+	for ; power > 0; power-- {
+		mult *= base
+	}
+	return mult, true
+}
+
+// decimalSeparator returns the decimal separator used in the locale.
+func decimalSeparator(tag language.Tag) string {
+	base, _ := tag.Base()
+	region, _ := tag.Region()
+	if _, ok := dotDecimalRegions[base.String()+"-"+region.String()]; ok {
+		return "."
+	}
+	if _, ok := commaDecimalLanguages[base.String()]; ok {
+		return ","
+	}
+	return "."
+}
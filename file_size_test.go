@@ -0,0 +1,121 @@
+package utils_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dmitrymomot/go-utils"
+)
+
+func TestFormatFileSize(t *testing.T) {
+	tests := []struct {
+		name     string
+		size     int64
+		opts     []utils.FileSizeOption
+		expected string
+	}{
+		{"zero", 0, nil, "0 B"},
+		{"bytes", 512, nil, "512 B"},
+		{"kibibytes", 1536, nil, "1.5 KiB"},
+		{"whole number", utils.MiB, nil, "1 MiB"},
+		{"rounded", 1500000, nil, "1.4 MiB"},
+		{"rounding reaches next unit", utils.MiB - 10, nil, "1 MiB"},
+		{"si units", 1500000, []utils.FileSizeOption{utils.WithSIUnits()}, "1.5 MB"},
+		{"si kilobytes", 999, []utils.FileSizeOption{utils.WithSIUnits()}, "999 B"},
+		{"si lowercase k", 1000, []utils.FileSizeOption{utils.WithSIUnits()}, "1 kB"},
+		{"precision", 1234567, []utils.FileSizeOption{utils.WithSIUnits(), utils.WithFileSizePrecision(3)}, "1.235 MB"},
+		{"zero precision", 1536, []utils.FileSizeOption{utils.WithFileSizePrecision(0)}, "2 KiB"},
+		{"trailing zeros trimmed", 1100, []utils.FileSizeOption{utils.WithSIUnits(), utils.WithFileSizePrecision(3)}, "1.1 kB"},
+		{"decimal separator", 1536, []utils.FileSizeOption{utils.WithDecimalSeparator(",")}, "1,5 KiB"},
+		{"locale", 1536, []utils.FileSizeOption{utils.WithFileSizeLocale("de-DE")}, "1,5 KiB"},
+		{"locale language only", 1536, []utils.FileSizeOption{utils.WithFileSizeLocale("fr")}, "1,5 KiB"},
+		{"locale region exception", 1536, []utils.FileSizeOption{utils.WithFileSizeLocale("de-CH")}, "1.5 KiB"},
+		{"dot locale", 1536, []utils.FileSizeOption{utils.WithFileSizeLocale("en-US")}, "1.5 KiB"},
+		{"invalid locale", 1536, []utils.FileSizeOption{utils.WithDecimalSeparator(","), utils.WithFileSizeLocale("???")}, "1,5 KiB"},
+		{"negative", -2048, nil, "-2 KiB"},
+		{"max", 1<<63 - 1, nil, "8 EiB"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := utils.FormatFileSize(tt.size, tt.opts...); got != tt.expected {
+				t.Errorf("got %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseFileSize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"0", 0},
+		{"1024", 1024},
+		{"10B", 10},
+		{"1 kB", 1000},
+		{"1KB", 1000},
+		{"10MB", 10 * utils.MB},
+		{"1.5 GB", 1500 * utils.MB},
+		{"0.29 kB", 290},
+		{"1KiB", 1024},
+		{"10 MiB", 10 * utils.MiB},
+		{"1.5gib", 3 * utils.GiB / 2},
+		{"2Ki", 2048},
+		{"512Mi", 512 * utils.MiB},
+		// Bare prefixes are decimal, as they were with gommon.
+		{"10M", 10 * utils.MB},
+		{"1k", 1000},
+		{"2G", 2 * utils.GB},
+		{".5 KiB", 512},
+		{"1.0001 KiB", 1024},
+		{"  3 TB  ", 3 * utils.TB},
+		{"1 EB", utils.EB},
+		{"7 EiB", 7 * utils.EiB},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := utils.ParseFileSize(tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("got %d, want %d", got, tt.expected)
+			}
+		})
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		for _, size := range []int64{0, 1, 1000, 1024, 5 * utils.MiB} {
+			for _, opt := range []utils.FileSizeOption{utils.WithIECUnits(), utils.WithSIUnits()} {
+				s := utils.FormatFileSize(size, opt, utils.WithFileSizePrecision(6))
+				if got, err := utils.ParseFileSize(s); err != nil || got != size {
+					t.Errorf("%q: got %d, %v, want %d", s, got, err, size)
+				}
+			}
+		}
+	})
+
+	errs := []struct {
+		input string
+		err   error
+	}{
+		{"", utils.ErrInvalidFileSizeFormat},
+		{"MB", utils.ErrInvalidFileSizeFormat},
+		{"-1 MB", utils.ErrInvalidFileSizeFormat},
+		{"1.2.3 MB", utils.ErrInvalidFileSizeFormat},
+		{". MB", utils.ErrInvalidFileSizeFormat},
+		{"10 XB", utils.ErrInvalidFileSizeFormat},
+		{"10 MBB", utils.ErrInvalidFileSizeFormat},
+		{"1,5 MB", utils.ErrInvalidFileSizeFormat},
+		{"8 EiB", utils.ErrFileSizeOverflow},
+		{"99999999999999999999", utils.ErrFileSizeOverflow},
+	}
+
+	for _, tt := range errs {
+		if _, err := utils.ParseFileSize(tt.input); !errors.Is(err, tt.err) {
+			t.Errorf("%q: got error %v, want %v", tt.input, err, tt.err)
+		}
+	}
+}
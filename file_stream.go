@@ -0,0 +1,182 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// fileStreamSniffLen is the number of bytes read ahead to detect the content type.
+const fileStreamSniffLen = 3072
+
+type (
+	// FileStream is an open file content with its metadata.
+	// The caller must close it.
+	FileStream struct {
+		io.ReadCloser
+		// Size is the content size in bytes, -1 if unknown.
+		Size int64
+		// ContentType is the media type without parameters, e.g. "image/png".
+		ContentType string
+		// FileName is the base file name, can be empty.
+		FileName string
+	}
+
+	// readCloser combines a reader with the closer of the underlying source.
+	readCloser struct {
+		io.Reader
+		io.Closer
+	}
+
+	// limitedReadCloser returns ErrFileTooLarge once more than limit bytes are read.
+	limitedReadCloser struct {
+		io.ReadCloser
+		limit int64
+		read  int64
+	}
+)
+
+// OpenFileByPath opens the file by the given path for streaming.
+// It's a streaming equivalent of GetFileByPathWithContext:
+// registered loaders which implement FileOpener stream the file, other loaders load it into memory.
+func OpenFileByPath(ctx context.Context, path string) (*FileStream, error) {
+	if path == "" {
+		return nil, fmt.Errorf("path cannot be empty")
+	}
+
+	loader, err := fileLoaderByPath(path)
+	if err != nil {
+		return nil, err
+	}
+	if loader == nil {
+		return openLocalFile(path)
+	}
+
+	if opener, ok := loader.(FileOpener); ok {
+		return opener.Open(ctx, path)
+	}
+
+	b, err := loader.Load(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return newFileStream(io.NopCloser(bytes.NewReader(b)), int64(len(b)), "", fileNameFromURI(path)), nil
+}
+
+// OpenDownload starts downloading the file from the given URL and returns the response body stream.
+// It's a streaming equivalent of DownloadFileWithContext, retries and checksum options are ignored.
+// Reading more than WithMaxDownloadSize bytes fails with ErrFileTooLarge.
+func OpenDownload(ctx context.Context, rawURL string, opts ...DownloadOption) (*FileStream, error) {
+	o := newDownloadOptions(opts...)
+
+	resp, err := openDownload(ctx, o, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var body io.ReadCloser = resp.Body
+	if o.maxSize > 0 {
+		body = &limitedReadCloser{ReadCloser: resp.Body, limit: o.maxSize}
+	}
+
+	name := fileNameFromURI(rawURL)
+	if cd, err := ParseContentDisposition(resp.Header.Get("Content-Disposition")); err == nil && cd.FileName != "" {
+		name = SanitizeFileName(cd.FileName)
+	}
+
+	return newFileStream(body, resp.ContentLength, resp.Header.Get("Content-Type"), name), nil
+}
+
+// openLocalFile opens the file from the local disk.
+func openLocalFile(name string) (*FileStream, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file from local disk: %w", err)
+	}
+
+	size := int64(-1)
+	if info, err := f.Stat(); err == nil {
+		size = info.Size()
+	}
+
+	return newFileStream(f, size, "", filepath.Base(name)), nil
+}
+
+// newFileStream returns a file stream with the detected content type.
+// If the content type is unknown, it's sniffed from the first bytes of the content,
+// and then guessed by the file name extension.
+func newFileStream(rc io.ReadCloser, size int64, contentType, name string) *FileStream {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		contentType = mediaType
+	} else {
+		contentType = strings.TrimSpace(strings.Split(contentType, ";")[0])
+	}
+
+	if contentType == "" || contentType == defaultMimeType {
+		// Read errors are not returned here, the next read from the source returns them to the caller.
+		head := make([]byte, fileStreamSniffLen)
+		n, _ := io.ReadFull(rc, head)
+		head = head[:n]
+
+		contentType = defaultMimeType
+		if n > 0 {
+			contentType, _ = GetFileContentTypeByBytes(head)
+		}
+		if contentType == defaultMimeType && name != "" {
+			contentType = GetFileTypeByURI(name)
+		}
+
+		rc = readCloser{Reader: io.MultiReader(bytes.NewReader(head), rc), Closer: rc}
+	}
+
+	return &FileStream{
+		ReadCloser:  rc,
+		Size:        size,
+		ContentType: contentType,
+		FileName:    name,
+	}
+}
+
+// fileNameFromURI returns the base name of the URI path, or an empty string.
+func fileNameFromURI(uri string) string {
+	p := uri
+	if u, err := url.Parse(uri); err == nil && u.Scheme != "" && u.Opaque == "" {
+		p = u.Path
+	}
+
+	name := path.Base(filepath.ToSlash(p))
+	if name == "." || name == "/" {
+		return ""
+	}
+
+	return name
+}
+
+// Read implements io.Reader.
+func (r *limitedReadCloser) Read(p []byte) (int, error) {
+	if r.read >= r.limit {
+		// Check whether there is anything left beyond the limit.
+		var b [1]byte
+		if n, _ := r.ReadCloser.Read(b[:]); n > 0 {
+			return 0, fmt.Errorf("%w: limit is %d bytes", ErrFileTooLarge, r.limit)
+		}
+		return 0, io.EOF
+	}
+
+	if rest := r.limit - r.read; int64(len(p)) > rest {
+		p = p[:rest]
+	}
+
+	n, err := r.ReadCloser.Read(p)
+	r.read += int64(n)
+
+	return n, err
+}
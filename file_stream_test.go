@@ -0,0 +1,171 @@
+package utils_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/dmitrymomot/go-utils"
+)
+
+func TestOpenDownload(t *testing.T) {
+	png := "\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 100)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/report":
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			w.Header().Set("Content-Disposition", `attachment; filename="report 2023.csv"`)
+			_, _ = w.Write([]byte("a,b\n1,2\n"))
+		case "/intl":
+			w.Header().Set("Content-Disposition", `attachment; filename="report.csv"; filename*=UTF-8''%D0%BE%D1%82%D1%87%D1%91%D1%82.csv`)
+			_, _ = w.Write([]byte("a,b\n"))
+		case "/images/photo.png":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			_, _ = w.Write([]byte(png))
+		case "/chunked":
+			w.(http.Flusher).Flush()
+			_, _ = w.Write([]byte(strings.Repeat("a", 1024)))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	t.Run("header metadata", func(t *testing.T) {
+		s, err := utils.OpenDownload(context.Background(), srv.URL+"/report")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer s.Close()
+
+		if s.ContentType != "text/csv" || s.FileName != "report 2023.csv" || s.Size != 8 {
+			t.Errorf("unexpected metadata: %+v", s)
+		}
+		data, _ := io.ReadAll(s)
+		if string(data) != "a,b\n1,2\n" {
+			t.Errorf("got wrong data: %q", data)
+		}
+	})
+
+	t.Run("extended file name", func(t *testing.T) {
+		s, err := utils.OpenDownload(context.Background(), srv.URL+"/intl")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer s.Close()
+
+		if s.FileName != "отчёт.csv" {
+			t.Errorf("got file name %q, want %q", s.FileName, "отчёт.csv")
+		}
+	})
+
+	t.Run("sniffed content type", func(t *testing.T) {
+		s, err := utils.OpenDownload(context.Background(), srv.URL+"/images/photo.png?size=large")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer s.Close()
+
+		if s.ContentType != "image/png" || s.FileName != "photo.png" {
+			t.Errorf("unexpected metadata: %+v", s)
+		}
+		data, _ := io.ReadAll(s)
+		if string(data) != png {
+			t.Errorf("got wrong data (%d bytes)", len(data))
+		}
+	})
+
+	t.Run("size limit", func(t *testing.T) {
+		s, err := utils.OpenDownload(context.Background(), srv.URL+"/chunked", utils.WithMaxDownloadSize(100))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer s.Close()
+
+		if _, err := io.ReadAll(s); !errors.Is(err, utils.ErrFileTooLarge) {
+			t.Errorf("expected error %v, got %v", utils.ErrFileTooLarge, err)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		if _, err := utils.OpenDownload(context.Background(), srv.URL+"/missing"); !errors.Is(err, utils.ErrUnexpectedStatus) {
+			t.Errorf("expected error %v, got %v", utils.ErrUnexpectedStatus, err)
+		}
+	})
+}
+
+func TestOpenFileByPath(t *testing.T) {
+	t.Run("local file", func(t *testing.T) {
+		s, err := utils.OpenFileByPath(context.Background(), "./testdata/testfile.txt")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer s.Close()
+
+		if s.ContentType != "text/plain" || s.FileName != "testfile.txt" || s.Size != 17 {
+			t.Errorf("unexpected metadata: %+v", s)
+		}
+		data, _ := io.ReadAll(s)
+		if string(data) != "test file content" {
+			t.Errorf("got wrong data: %q", data)
+		}
+	})
+
+	t.Run("data uri", func(t *testing.T) {
+		s, err := utils.OpenFileByPath(context.Background(), "data:application/json;base64,eyJhIjoxfQ==")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer s.Close()
+
+		if s.ContentType != "application/json" || s.Size != 7 {
+			t.Errorf("unexpected metadata: %+v", s)
+		}
+	})
+
+	t.Run("fs loader", func(t *testing.T) {
+		utils.RegisterFileLoader("stream", utils.NewFSFileLoader(fstest.MapFS{
+			"docs/readme.md": {Data: []byte("# Title\n")},
+		}))
+		defer utils.RegisterFileLoader("stream", nil)
+
+		s, err := utils.OpenFileByPath(context.Background(), "stream://docs/readme.md")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer s.Close()
+
+		if s.ContentType != "text/plain" || s.FileName != "readme.md" || s.Size != 8 {
+			t.Errorf("unexpected metadata: %+v", s)
+		}
+	})
+
+	t.Run("loader without streaming", func(t *testing.T) {
+		utils.RegisterFileLoader("bytes", utils.FileLoaderFunc(func(ctx context.Context, uri string) ([]byte, error) {
+			return []byte("<html><body></body></html>"), nil
+		}))
+		defer utils.RegisterFileLoader("bytes", nil)
+
+		s, err := utils.OpenFileByPath(context.Background(), "bytes://pages/index.html")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer s.Close()
+
+		if s.ContentType != "text/html" || s.FileName != "index.html" || s.Size != 26 {
+			t.Errorf("unexpected metadata: %+v", s)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		if _, err := utils.OpenFileByPath(context.Background(), "./testdata/missing.txt"); err == nil {
+			t.Error("expected error but got none")
+		}
+	})
+}
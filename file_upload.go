@@ -0,0 +1,353 @@
+package utils
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"sync"
+	"time"
+)
+
+// Default part upload settings.
+const (
+	DefaultUploadConcurrency = 4
+	DefaultUploadMinBackoff  = 500 * time.Millisecond
+	DefaultUploadMaxBackoff  = 30 * time.Second
+)
+
+type (
+	// PartUpload is a single part passed to PartUploadFunc.
+	PartUpload struct {
+		FilePart
+		// Body is the part content positioned at the beginning.
+		Body io.ReadSeeker
+		// Checksum is the raw digest of the part content, nil if part checksums are disabled.
+		// With the default MD5 hash it can be sent as base64 encoded Content-MD5 header.
+		Checksum []byte
+		// Attempt is the 1-based attempt number, greater than 1 for retries.
+		Attempt int
+	}
+
+	// PartUploadFunc uploads a single part and returns its identifier, e.g. the ETag returned by S3.
+	// It's called concurrently for different parts. Return a permanent error (see PermanentUploadError)
+	// to stop retrying the part.
+	PartUploadFunc func(ctx context.Context, part PartUpload) (string, error)
+
+	// UploadedPart is a successfully uploaded part.
+	UploadedPart struct {
+		FilePart
+		// ETag is the part identifier returned by PartUploadFunc.
+		ETag string
+		// Checksum is the raw digest of the part content, nil if part checksums are disabled.
+		Checksum []byte
+	}
+
+	// PartUploadResult is the result of UploadParts.
+	PartUploadResult struct {
+		// Size is the total number of uploaded bytes.
+		Size int64
+		// Parts is the list of uploaded parts in part number order.
+		Parts []UploadedPart
+		// Checksum is the raw digest of the whole file, nil if the file checksum is disabled.
+		Checksum []byte
+	}
+
+	// PartUploadProgress is reported after every uploaded part.
+	PartUploadProgress struct {
+		UploadedBytes  int64
+		TotalBytes     int64
+		UploadedParts  int
+		TotalParts     int
+		LastPartNumber int
+	}
+
+	// PartUploadOption configures UploadParts.
+	PartUploadOption func(*partUploadOptions)
+
+	partUploadOptions struct {
+		concurrency int
+		retries     int
+		minBackoff  time.Duration
+		maxBackoff  time.Duration
+		newPartHash func() hash.Hash
+		newFileHash func() hash.Hash
+		progress    func(PartUploadProgress)
+	}
+
+	// permanentUploadError marks an error which must not be retried.
+	permanentUploadError struct {
+		err error
+	}
+
+	// contextReader stops reading when the context is done.
+	contextReader struct {
+		ctx context.Context
+		r   io.Reader
+	}
+)
+
+// WithUploadConcurrency sets the max number of parts uploaded at the same time.
+// Defaults to DefaultUploadConcurrency.
+func WithUploadConcurrency(n int) PartUploadOption {
+	return func(o *partUploadOptions) {
+		if n > 0 {
+			o.concurrency = n
+		}
+	}
+}
+
+// WithUploadRetries sets how many times a failed part is retried. Defaults to 0.
+func WithUploadRetries(n int) PartUploadOption {
+	return func(o *partUploadOptions) {
+		if n >= 0 {
+			o.retries = n
+		}
+	}
+}
+
+// WithUploadRetryBackoff sets the min and max delay between retries of a part.
+// The delay is doubled after each attempt, up to the max delay.
+func WithUploadRetryBackoff(minDelay, maxDelay time.Duration) PartUploadOption {
+	return func(o *partUploadOptions) {
+		if minDelay > 0 {
+			o.minBackoff = minDelay
+		}
+		if maxDelay >= o.minBackoff {
+			o.maxBackoff = maxDelay
+		}
+	}
+}
+
+// WithPartChecksum sets the hash used for part checksums. Defaults to MD5, nil disables part checksums.
+func WithPartChecksum(newHash func() hash.Hash) PartUploadOption {
+	return func(o *partUploadOptions) {
+		o.newPartHash = newHash
+	}
+}
+
+// WithFileChecksum sets the hash used for the whole file checksum. Defaults to SHA-256, nil disables it.
+func WithFileChecksum(newHash func() hash.Hash) PartUploadOption {
+	return func(o *partUploadOptions) {
+		o.newFileHash = newHash
+	}
+}
+
+// WithUploadProgress sets the function called after every uploaded part.
+// Calls are serialized, so the function doesn't need to be safe for concurrent use.
+func WithUploadProgress(fn func(PartUploadProgress)) PartUploadOption {
+	return func(o *partUploadOptions) {
+		o.progress = fn
+	}
+}
+
+// PermanentUploadError wraps the error returned by PartUploadFunc, so the part is not retried.
+func PermanentUploadError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentUploadError{err: err}
+}
+
+func (e *permanentUploadError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentUploadError) Unwrap() error {
+	return e.err
+}
+
+// UploadParts uploads the parts of the plan (see PlanFileParts) reading their content from r.
+// Up to WithUploadConcurrency parts are uploaded at the same time. A failed part is retried
+// with exponential backoff (see WithUploadRetries), unless the error is permanent or an
+// *HTTPStatusError with a non-temporary status. The first failed part cancels the remaining uploads.
+// Part checksums are passed to the upload function, the whole file checksum is computed concurrently.
+// On error the result contains the successfully uploaded parts and their total size only.
+// Possible errors: ErrInvalidReader and ErrUploadFailed wrapping the part error or the context error.
+func UploadParts(ctx context.Context, r io.ReaderAt, plan FilePartPlan, upload PartUploadFunc, opts ...PartUploadOption) (PartUploadResult, error) {
+	if r == nil {
+		return PartUploadResult{}, fmt.Errorf("invalid reader: %w", ErrInvalidReader)
+	}
+	if upload == nil {
+		return PartUploadResult{}, fmt.Errorf("%w: upload function is nil", ErrUploadFailed)
+	}
+
+	o := &partUploadOptions{
+		concurrency: DefaultUploadConcurrency,
+		minBackoff:  DefaultUploadMinBackoff,
+		maxBackoff:  DefaultUploadMaxBackoff,
+		newPartHash: md5.New,
+		newFileHash: sha256.New,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	uploadCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+		result   = PartUploadResult{Size: plan.Size, Parts: make([]UploadedPart, len(plan.Parts))}
+		progress = PartUploadProgress{TotalBytes: plan.Size, TotalParts: len(plan.Parts)}
+	)
+
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+
+	if o.newFileHash != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h := o.newFileHash()
+			body := &contextReader{ctx: uploadCtx, r: io.NewSectionReader(r, 0, plan.Size)}
+			if _, err := io.Copy(h, body); err != nil {
+				fail(wrapKind(ErrUploadFailed, fmt.Errorf("failed to compute file checksum: %w", err)))
+				return
+			}
+			result.Checksum = h.Sum(nil)
+		}()
+	}
+
+	jobs := make(chan int)
+	workers := o.concurrency
+	if workers > len(plan.Parts) {
+		workers = len(plan.Parts)
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				part, err := o.uploadPart(uploadCtx, r, plan.Parts[idx], upload)
+				if err != nil {
+					fail(err)
+					continue
+				}
+
+				mu.Lock()
+				result.Parts[idx] = part
+				progress.UploadedBytes += part.Length
+				progress.UploadedParts++
+				progress.LastPartNumber = part.Number
+				if o.progress != nil {
+					o.progress(progress)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for i := range plan.Parts {
+		select {
+		case jobs <- i:
+		case <-uploadCtx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr == nil && ctx.Err() != nil {
+		firstErr = wrapKind(ErrUploadFailed, ctx.Err())
+	}
+	if firstErr != nil {
+		// Keep the uploaded parts only, e.g. to resume or abort the upload.
+		uploaded := result.Parts[:0]
+		result.Size = 0
+		for _, p := range result.Parts {
+			if p.Number > 0 {
+				uploaded = append(uploaded, p)
+				result.Size += p.Length
+			}
+		}
+		result.Parts = uploaded
+		return result, firstErr
+	}
+
+	return result, nil
+}
+
+// uploadPart computes the part checksum and uploads the part, retrying failed attempts.
+func (o *partUploadOptions) uploadPart(ctx context.Context, r io.ReaderAt, part FilePart, upload PartUploadFunc) (UploadedPart, error) {
+	body := part.Section(r)
+
+	var sum []byte
+	if o.newPartHash != nil {
+		h := o.newPartHash()
+		if _, err := io.Copy(h, &contextReader{ctx: ctx, r: body}); err != nil {
+			return UploadedPart{}, wrapKind(ErrUploadFailed, fmt.Errorf("part %d: failed to compute checksum: %w", part.Number, err))
+		}
+		sum = h.Sum(nil)
+	}
+
+	for attempt := 1; ; attempt++ {
+		// Another part may have failed in the meantime, don't start a doomed upload.
+		if err := ctx.Err(); err != nil {
+			return UploadedPart{}, wrapKind(ErrUploadFailed, fmt.Errorf("part %d: %w", part.Number, err))
+		}
+		if _, err := body.Seek(0, io.SeekStart); err != nil {
+			return UploadedPart{}, wrapKind(ErrUploadFailed, fmt.Errorf("part %d: %w", part.Number, err))
+		}
+
+		etag, err := upload(ctx, PartUpload{
+			FilePart: part,
+			Body:     body,
+			Checksum: sum,
+			Attempt:  attempt,
+		})
+		if err == nil {
+			return UploadedPart{FilePart: part, ETag: etag, Checksum: sum}, nil
+		}
+		if attempt > o.retries || !isRetryableUploadError(ctx, err) {
+			return UploadedPart{}, wrapKind(ErrUploadFailed, fmt.Errorf("part %d: %w", part.Number, err))
+		}
+
+		timer := time.NewTimer(retryBackoff(attempt, o.minBackoff, o.maxBackoff))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return UploadedPart{}, wrapKind(ErrUploadFailed, fmt.Errorf("part %d: %w", part.Number, ctx.Err()))
+		case <-timer.C:
+		}
+	}
+}
+
+// isRetryableUploadError reports whether the failed part upload can be retried.
+func isRetryableUploadError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var permanentErr *permanentUploadError
+	if errors.As(err, &permanentErr) {
+		return false
+	}
+
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return isRetryableStatus(statusErr.StatusCode)
+	}
+
+	return true
+}
+
+// Read implements io.Reader, the context is checked before every read.
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
@@ -0,0 +1,232 @@
+package utils_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dmitrymomot/go-utils"
+)
+
+func TestUploadParts(t *testing.T) {
+	content := strings.Repeat("0123456789", 10)
+	plan, err := utils.PlanFileParts(int64(len(content)), utils.WithMinPartSize(16), utils.WithMaxPartSize(16))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	t.Run("uploads all parts", func(t *testing.T) {
+		var (
+			mu       sync.Mutex
+			received = make(map[int]string)
+			active   int32
+			maxSeen  int32
+			progress []utils.PartUploadProgress
+		)
+
+		upload := func(ctx context.Context, p utils.PartUpload) (string, error) {
+			n := atomic.AddInt32(&active, 1)
+			defer atomic.AddInt32(&active, -1)
+			for {
+				m := atomic.LoadInt32(&maxSeen)
+				if n <= m || atomic.CompareAndSwapInt32(&maxSeen, m, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+
+			data, err := io.ReadAll(p.Body)
+			if err != nil {
+				return "", err
+			}
+			if sum := md5.Sum(data); !bytes.Equal(sum[:], p.Checksum) {
+				return "", fmt.Errorf("part %d: checksum mismatch", p.Number)
+			}
+
+			mu.Lock()
+			received[p.Number] = string(data)
+			mu.Unlock()
+			return fmt.Sprintf("etag-%d", p.Number), nil
+		}
+
+		result, err := utils.UploadParts(context.Background(), strings.NewReader(content), plan, upload,
+			utils.WithUploadConcurrency(3),
+			utils.WithUploadProgress(func(p utils.PartUploadProgress) {
+				progress = append(progress, p)
+			}),
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(result.Parts) != len(plan.Parts) {
+			t.Fatalf("got %d parts, want %d", len(result.Parts), len(plan.Parts))
+		}
+		var sb strings.Builder
+		for i, p := range result.Parts {
+			if p.Number != i+1 || p.ETag != fmt.Sprintf("etag-%d", i+1) {
+				t.Errorf("unexpected part: %+v", p)
+			}
+			sb.WriteString(received[p.Number])
+		}
+		if sb.String() != content {
+			t.Errorf("got content %q, want %q", sb.String(), content)
+		}
+
+		if sum := sha256.Sum256([]byte(content)); !bytes.Equal(result.Checksum, sum[:]) {
+			t.Errorf("got file checksum %x, want %x", result.Checksum, sum)
+		}
+		if maxSeen > 3 {
+			t.Errorf("got %d concurrent uploads, want at most 3", maxSeen)
+		}
+
+		if len(progress) != len(plan.Parts) {
+			t.Fatalf("got %d progress reports, want %d", len(progress), len(plan.Parts))
+		}
+		last := progress[len(progress)-1]
+		if last.UploadedBytes != int64(len(content)) || last.UploadedParts != len(plan.Parts) || last.TotalParts != len(plan.Parts) {
+			t.Errorf("unexpected progress: %+v", last)
+		}
+	})
+
+	t.Run("retries failed parts", func(t *testing.T) {
+		var attempts int32
+		upload := func(ctx context.Context, p utils.PartUpload) (string, error) {
+			data, _ := io.ReadAll(p.Body)
+			if p.Number == 2 && p.Attempt < 3 {
+				atomic.AddInt32(&attempts, 1)
+				return "", errors.New("connection reset")
+			}
+			if int64(len(data)) != p.Length {
+				return "", fmt.Errorf("got %d bytes, want %d", len(data), p.Length)
+			}
+			return "ok", nil
+		}
+
+		result, err := utils.UploadParts(context.Background(), strings.NewReader(content), plan, upload,
+			utils.WithUploadRetries(2),
+			utils.WithUploadRetryBackoff(time.Millisecond, time.Millisecond),
+			utils.WithPartChecksum(nil),
+			utils.WithFileChecksum(nil),
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if attempts != 2 {
+			t.Errorf("got %d failed attempts, want 2", attempts)
+		}
+		if result.Checksum != nil || result.Parts[0].Checksum != nil {
+			t.Errorf("checksums must be disabled: %+v", result)
+		}
+	})
+
+	t.Run("permanent error cancels upload", func(t *testing.T) {
+		errDenied := errors.New("access denied")
+		var calls int32
+		upload := func(ctx context.Context, p utils.PartUpload) (string, error) {
+			atomic.AddInt32(&calls, 1)
+			if p.Number == 1 {
+				return "", utils.PermanentUploadError(errDenied)
+			}
+			select {
+			case <-ctx.Done():
+				return "", ctx.Err()
+			case <-time.After(time.Second):
+				return "ok", nil
+			}
+		}
+
+		result, err := utils.UploadParts(context.Background(), strings.NewReader(content), plan, upload,
+			utils.WithUploadConcurrency(2),
+			utils.WithUploadRetries(5),
+		)
+		if !errors.Is(err, utils.ErrUploadFailed) || !errors.Is(err, errDenied) {
+			t.Fatalf("got error %v, want %v", err, errDenied)
+		}
+		if len(result.Parts) != 0 {
+			t.Errorf("got %d uploaded parts, want 0", len(result.Parts))
+		}
+		if calls > 3 {
+			t.Errorf("got %d calls, remaining parts must be skipped", calls)
+		}
+	})
+
+	t.Run("partial result", func(t *testing.T) {
+		errDenied := errors.New("access denied")
+		var calls int32
+		upload := func(ctx context.Context, p utils.PartUpload) (string, error) {
+			atomic.AddInt32(&calls, 1)
+			if ctx.Err() != nil {
+				t.Errorf("part %d: upload called with a canceled context", p.Number)
+			}
+			if p.Number == 2 {
+				return "", utils.PermanentUploadError(errDenied)
+			}
+			return "ok", nil
+		}
+
+		result, err := utils.UploadParts(context.Background(), strings.NewReader(content), plan, upload,
+			utils.WithUploadConcurrency(1),
+			utils.WithPartChecksum(nil),
+			utils.WithFileChecksum(nil),
+		)
+		if !errors.Is(err, errDenied) {
+			t.Fatalf("got error %v, want %v", err, errDenied)
+		}
+		if len(result.Parts) != 1 || result.Size != result.Parts[0].Length {
+			t.Errorf("got size %d and %d parts, want the first part only", result.Size, len(result.Parts))
+		}
+		if calls != 2 {
+			t.Errorf("got %d calls, want 2", calls)
+		}
+	})
+
+	t.Run("non-retryable status", func(t *testing.T) {
+		var calls int32
+		upload := func(ctx context.Context, p utils.PartUpload) (string, error) {
+			atomic.AddInt32(&calls, 1)
+			return "", &utils.HTTPStatusError{StatusCode: http.StatusForbidden, Status: "403 Forbidden"}
+		}
+
+		single, _ := utils.PlanFileParts(10)
+		_, err := utils.UploadParts(context.Background(), strings.NewReader(content), single, upload, utils.WithUploadRetries(3))
+		if !errors.Is(err, utils.ErrUnexpectedStatus) {
+			t.Fatalf("got error %v, want %v", err, utils.ErrUnexpectedStatus)
+		}
+		if calls != 1 {
+			t.Errorf("got %d calls, want 1", calls)
+		}
+	})
+
+	t.Run("canceled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		upload := func(ctx context.Context, p utils.PartUpload) (string, error) {
+			cancel()
+			return "", ctx.Err()
+		}
+
+		_, err := utils.UploadParts(ctx, strings.NewReader(content), plan, upload, utils.WithUploadRetries(3))
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("got error %v, want %v", err, context.Canceled)
+		}
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		if _, err := utils.UploadParts(context.Background(), nil, plan, nil); !errors.Is(err, utils.ErrInvalidReader) {
+			t.Errorf("got error %v, want %v", err, utils.ErrInvalidReader)
+		}
+		if _, err := utils.UploadParts(context.Background(), strings.NewReader(content), plan, nil); !errors.Is(err, utils.ErrUploadFailed) {
+			t.Errorf("got error %v, want %v", err, utils.ErrUploadFailed)
+		}
+	})
+}
@@ -0,0 +1,193 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"syscall"
+)
+
+type (
+	// AtomicWriteOption configures WriteFileAtomic.
+	AtomicWriteOption func(*atomicWriteOptions)
+
+	atomicWriteOptions struct {
+		syncDir      bool
+		preservePerm bool
+	}
+)
+
+// WithDirSync syncs the parent directory after the rename, so the new directory entry
+// survives a crash too. It's a no-op on Windows.
+func WithDirSync() AtomicWriteOption {
+	return func(o *atomicWriteOptions) {
+		o.syncDir = true
+	}
+}
+
+// WithoutPreservedPermissions applies the given permissions even if the file already exists.
+// By default the permissions of the replaced file are kept.
+func WithoutPreservedPermissions() AtomicWriteOption {
+	return func(o *atomicWriteOptions) {
+		o.preservePerm = false
+	}
+}
+
+// WriteFileAtomic writes data to the named file, so readers see either the old or the new content,
+// but never a partially written file.
+// The data is written to a temporary file in the same directory, synced to disk and renamed over the target.
+// perm is used for new files, it's applied as is, without umask.
+// If the path is a symlink, the link itself is replaced.
+func WriteFileAtomic(path string, data []byte, perm fs.FileMode, opts ...AtomicWriteOption) error {
+	_, err := WriteFileAtomicFrom(path, bytes.NewReader(data), perm, opts...)
+	return err
+}
+
+// WriteFileAtomicFrom is like WriteFileAtomic, but copies the content from the reader.
+// It returns the number of bytes written. The target is left untouched if reading fails.
+func WriteFileAtomicFrom(path string, r io.Reader, perm fs.FileMode, opts ...AtomicWriteOption) (int64, error) {
+	if r == nil {
+		return 0, fmt.Errorf("invalid reader: %w", ErrInvalidReader)
+	}
+
+	o := &atomicWriteOptions{preservePerm: true}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if o.preservePerm {
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			perm = info.Mode().Perm()
+		}
+	}
+
+	dir, name := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+
+	f, err := os.CreateTemp(dir, "."+name+".tmp*")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmp := f.Name()
+	defer func() {
+		// The temporary file is renamed on success, so this only cleans up after failures.
+		_ = os.Remove(tmp)
+	}()
+
+	n, err := io.Copy(f, r)
+	if err != nil {
+		f.Close()
+		return n, fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := f.Chmod(perm.Perm()); err != nil {
+		f.Close()
+		return n, fmt.Errorf("failed to set file permissions: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return n, fmt.Errorf("failed to sync file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return n, fmt.Errorf("failed to close file: %w", err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return n, fmt.Errorf("failed to replace file: %w", err)
+	}
+
+	if o.syncDir {
+		if err := syncDir(dir); err != nil {
+			return n, fmt.Errorf("failed to sync directory: %w", err)
+		}
+	}
+
+	return n, nil
+}
+
+// TempFile creates a new temporary file like os.CreateTemp.
+// The file is closed and removed when the context is done or the cleanup function is called,
+// whichever happens first. The cleanup function is safe to call multiple times,
+// it returns once the file is removed.
+func TempFile(ctx context.Context, dir, pattern string) (*os.File, func(), error) {
+	f, err := os.CreateTemp(dir, pattern)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+
+	cleanup := scopedCleanup(ctx, func() {
+		f.Close()
+		_ = os.Remove(f.Name())
+	})
+
+	return f, cleanup, nil
+}
+
+// TempDir creates a new temporary directory like os.MkdirTemp.
+// The directory and its content are removed when the context is done or the cleanup function is called,
+// whichever happens first. The cleanup function is safe to call multiple times,
+// it returns once the directory is removed.
+func TempDir(ctx context.Context, dir, pattern string) (string, func(), error) {
+	name, err := os.MkdirTemp(dir, pattern)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+
+	cleanup := scopedCleanup(ctx, func() {
+		_ = os.RemoveAll(name)
+	})
+
+	return name, cleanup, nil
+}
+
+// scopedCleanup runs fn once, when the context is done or the returned function is called.
+func scopedCleanup(ctx context.Context, fn func()) func() {
+	var once sync.Once
+	stop := make(chan struct{})
+
+	cleanup := func() {
+		once.Do(func() {
+			close(stop)
+			fn()
+		})
+	}
+
+	if done := ctx.Done(); done != nil {
+		go func() {
+			select {
+			case <-done:
+				cleanup()
+			case <-stop:
+			}
+		}()
+	}
+
+	return cleanup
+}
+
+// syncDir flushes the directory entries to disk.
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	// Some file systems don't support syncing directories.
+	if err := d.Sync(); err != nil && !errors.Is(err, syscall.EINVAL) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package utils_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/dmitrymomot/go-utils"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	t.Run("new file", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "config.json")
+
+		if err := utils.WriteFileAtomic(path, []byte(`{"a":1}`), 0o640, utils.WithDirSync()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		assertFileContent(t, path, `{"a":1}`)
+		assertDirEntries(t, dir, 1)
+
+		if runtime.GOOS != "windows" {
+			info, _ := os.Stat(path)
+			if info.Mode().Perm() != 0o640 {
+				t.Errorf("got mode %v, want %v", info.Mode().Perm(), os.FileMode(0o640))
+			}
+		}
+	})
+
+	t.Run("replace file", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("unix permissions")
+		}
+
+		path := filepath.Join(t.TempDir(), "config.json")
+		if err := os.WriteFile(path, []byte("old content"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(path, 0o604); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := utils.WriteFileAtomic(path, []byte("new"), 0o644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		assertFileContent(t, path, "new")
+		if info, _ := os.Stat(path); info.Mode().Perm() != 0o604 {
+			t.Errorf("permissions must be preserved, got %v", info.Mode().Perm())
+		}
+
+		if err := utils.WriteFileAtomic(path, []byte("newer"), 0o644, utils.WithoutPreservedPermissions()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if info, _ := os.Stat(path); info.Mode().Perm() != 0o644 {
+			t.Errorf("got mode %v, want %v", info.Mode().Perm(), os.FileMode(0o644))
+		}
+	})
+
+	t.Run("failed read keeps the file", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "data.txt")
+		if err := os.WriteFile(path, []byte("original"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		r := io.MultiReader(strings.NewReader("partial"), &errReader{})
+		if _, err := utils.WriteFileAtomicFrom(path, r, 0o600); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+		assertFileContent(t, path, "original")
+		assertDirEntries(t, dir, 1)
+	})
+
+	t.Run("reader", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "data.txt")
+		n, err := utils.WriteFileAtomicFrom(path, strings.NewReader("streamed"), 0o600)
+		if err != nil || n != 8 {
+			t.Fatalf("got %d, %v", n, err)
+		}
+		assertFileContent(t, path, "streamed")
+
+		if _, err := utils.WriteFileAtomicFrom(path, nil, 0o600); !errors.Is(err, utils.ErrInvalidReader) {
+			t.Errorf("got error %v, want %v", err, utils.ErrInvalidReader)
+		}
+	})
+
+	t.Run("missing directory", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "missing", "data.txt")
+		if err := utils.WriteFileAtomic(path, []byte("x"), 0o600); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("got error %v, want %v", err, os.ErrNotExist)
+		}
+	})
+}
+
+func TestTempFile(t *testing.T) {
+	t.Run("context done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		f, cleanup, err := utils.TempFile(ctx, t.TempDir(), "upload-*.bin")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := f.WriteString("data"); err != nil {
+			t.Fatal(err)
+		}
+
+		cancel()
+		// Waits for the cleanup triggered by the context.
+		cleanup()
+
+		if _, err := os.Stat(f.Name()); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("temporary file must be removed, got %v", err)
+		}
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		f, cleanup, err := utils.TempFile(context.Background(), t.TempDir(), "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		cleanup()
+		cleanup()
+
+		if _, err := os.Stat(f.Name()); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("temporary file must be removed, got %v", err)
+		}
+	})
+}
+
+func TestTempDir(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	dir, cleanup, err := utils.TempDir(ctx, t.TempDir(), "work-*")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cancel()
+	cleanup()
+
+	if _, err := os.Stat(dir); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("temporary directory must be removed, got %v", err)
+	}
+}
+
+func assertDirEntries(t *testing.T, dir string, expected int) {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != expected {
+		t.Errorf("got %d entries in %s, want %d", len(entries), dir, expected)
+	}
+}
@@ -4,17 +4,16 @@ go 1.20
 
 require (
 	github.com/gabriel-vasile/mimetype v1.4.2
-	github.com/labstack/gommon v0.4.0
 	github.com/mcnijman/go-emailaddress v1.1.0
 	github.com/mr-tron/base58 v1.2.0
-	github.com/pkg/errors v0.9.1
 	github.com/stretchr/testify v1.7.0
 	github.com/test-go/testify v1.1.4
+	golang.org/x/net v0.9.0
+	golang.org/x/text v0.13.0
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	golang.org/x/net v0.9.0 // indirect
 	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b // indirect
 )
@@ -0,0 +1,394 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+)
+
+// DefaultImageProbeBudget is the default max number of bytes read by ProbeImage.
+// It's enough for the EXIF data of most photos, which precedes the JPEG frame header.
+const DefaultImageProbeBudget = 256 << 10
+
+// Image formats returned by ProbeImage.
+const (
+	ImageFormatPNG  = "png"
+	ImageFormatJPEG = "jpeg"
+	ImageFormatGIF  = "gif"
+	ImageFormatWebP = "webp"
+	ImageFormatBMP  = "bmp"
+)
+
+// exifOrientationTag is the EXIF tag of the image orientation.
+const exifOrientationTag = 0x0112
+
+type (
+	// ImageInfo is the image metadata read from the file header.
+	ImageInfo struct {
+		// Format is the image format, e.g. "png" or "jpeg".
+		Format string
+		// ContentType is the media type detected from the content, e.g. "image/png".
+		ContentType string
+		// Width and Height are the stored image dimensions in pixels, before the orientation is applied.
+		Width  int
+		Height int
+		// Orientation is the EXIF orientation from 1 to 8, 1 if the image has no orientation.
+		Orientation int
+		// Animated is true for animated GIF, PNG (APNG) and WebP images.
+		Animated bool
+	}
+
+	// ImageProbeOption configures ProbeImage.
+	ImageProbeOption func(*imageProbeOptions)
+
+	imageProbeOptions struct {
+		budget int64
+	}
+)
+
+// WithImageProbeBudget sets the max number of bytes read by ProbeImage.
+// Defaults to DefaultImageProbeBudget.
+func WithImageProbeBudget(n int64) ImageProbeOption {
+	return func(o *imageProbeOptions) {
+		if n > 0 {
+			o.budget = n
+		}
+	}
+}
+
+// DisplaySize returns the dimensions of the image as displayed,
+// width and height are swapped for orientations rotated by 90 degrees.
+func (i ImageInfo) DisplaySize() (int, int) {
+	if i.Orientation >= 5 && i.Orientation <= 8 {
+		return i.Height, i.Width
+	}
+	return i.Width, i.Height
+}
+
+// ProbeImage reads the image metadata from the header without decoding the image:
+// format, dimensions, EXIF orientation and whether the image is animated.
+// PNG, JPEG, GIF, WebP and BMP images are supported, the format is detected with GetFileContentTypeByBytes.
+// At most the budget bytes are read (see WithImageProbeBudget). If the orientation or animation
+// data lies beyond the budget, the defaults are returned.
+// Possible errors: ErrInvalidReader, ErrEmptyInput, ErrUnsupportedImage and ErrInvalidImage.
+func ProbeImage(r io.Reader, opts ...ImageProbeOption) (ImageInfo, error) {
+	if r == nil {
+		return ImageInfo{}, fmt.Errorf("invalid reader: %w", ErrInvalidReader)
+	}
+
+	o := &imageProbeOptions{budget: DefaultImageProbeBudget}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	head, err := io.ReadAll(io.LimitReader(r, o.budget))
+	if err != nil {
+		return ImageInfo{}, fmt.Errorf("failed to read image: %w", err)
+	}
+
+	contentType, err := GetFileContentTypeByBytes(head)
+	if err != nil {
+		return ImageInfo{}, err
+	}
+
+	info := ImageInfo{ContentType: contentType, Orientation: 1}
+	switch contentType {
+	case "image/png", "image/vnd.mozilla.apng":
+		info.Format = ImageFormatPNG
+		err = probePNG(head, &info)
+	case "image/jpeg":
+		info.Format = ImageFormatJPEG
+		err = probeJPEG(head, &info)
+	case "image/gif":
+		info.Format = ImageFormatGIF
+		err = probeGIF(head, &info)
+	case "image/webp":
+		info.Format = ImageFormatWebP
+		err = probeWebP(head, &info)
+	case "image/bmp":
+		info.Format = ImageFormatBMP
+		err = probeBMP(head, &info)
+	default:
+		return ImageInfo{}, fmt.Errorf("%w: %s", ErrUnsupportedImage, contentType)
+	}
+	if err != nil {
+		return ImageInfo{}, err
+	}
+
+	if info.Width <= 0 || info.Height <= 0 {
+		return ImageInfo{}, fmt.Errorf("%w: invalid dimensions %dx%d", ErrInvalidImage, info.Width, info.Height)
+	}
+
+	return info, nil
+}
+
+// ProbeImageFile reads the metadata of the image file at the given path, see ProbeImage.
+func ProbeImageFile(path string, opts ...ImageProbeOption) (ImageInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return ImageInfo{}, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	return ProbeImage(f, opts...)
+}
+
+// probePNG reads the IHDR chunk and looks for the APNG animation control chunk before the image data.
+func probePNG(b []byte, info *ImageInfo) error {
+	// Signature, IHDR length and type, width and height.
+	if len(b) < 24 || string(b[12:16]) != "IHDR" {
+		return fmt.Errorf("%w: missing PNG header", ErrInvalidImage)
+	}
+	info.Width = int(binary.BigEndian.Uint32(b[16:20]))
+	info.Height = int(binary.BigEndian.Uint32(b[20:24]))
+
+	for off := 8; off+8 <= len(b); {
+		size := int(binary.BigEndian.Uint32(b[off : off+4]))
+		typ := string(b[off+4 : off+8])
+		switch typ {
+		case "acTL":
+			if off+12 <= len(b) {
+				info.Animated = binary.BigEndian.Uint32(b[off+8:off+12]) > 1
+			}
+			return nil
+		case "IDAT", "IEND":
+			return nil
+		}
+		if size < 0 || size > len(b) {
+			return nil
+		}
+		off += 12 + size
+	}
+
+	return nil
+}
+
+// probeJPEG scans the segments up to the frame header, the EXIF segment precedes it.
+func probeJPEG(b []byte, info *ImageInfo) error {
+	for off := 2; off+4 <= len(b); {
+		if b[off] != 0xff {
+			return fmt.Errorf("%w: invalid JPEG marker at %d", ErrInvalidImage, off)
+		}
+		marker := b[off+1]
+		switch {
+		case marker == 0xff:
+			// Fill byte.
+			off++
+			continue
+		case marker == 0x01 || (marker >= 0xd0 && marker <= 0xd8):
+			// Standalone markers without length.
+			off += 2
+			continue
+		}
+
+		size := int(binary.BigEndian.Uint16(b[off+2 : off+4]))
+		if size < 2 {
+			return fmt.Errorf("%w: invalid JPEG segment length", ErrInvalidImage)
+		}
+		data := b[off+4 : minInt(off+2+size, len(b))]
+
+		switch {
+		case marker == 0xe1 && bytes.HasPrefix(data, []byte("Exif\x00\x00")):
+			if o := exifOrientation(data[6:]); o > 0 {
+				info.Orientation = o
+			}
+		case marker >= 0xc0 && marker <= 0xcf && marker != 0xc4 && marker != 0xc8 && marker != 0xcc:
+			// Start of frame: precision, height, width.
+			if len(data) < 5 {
+				break
+			}
+			info.Height = int(binary.BigEndian.Uint16(data[1:3]))
+			info.Width = int(binary.BigEndian.Uint16(data[3:5]))
+			return nil
+		case marker == 0xda || marker == 0xd9:
+			// Start of scan or end of image without a frame header.
+			return fmt.Errorf("%w: missing JPEG frame header", ErrInvalidImage)
+		}
+
+		off += 2 + size
+	}
+
+	return fmt.Errorf("%w: JPEG frame header not found within the budget", ErrInvalidImage)
+}
+
+// probeGIF reads the logical screen size and walks the blocks looking for the
+// NETSCAPE looping extension or a second frame.
+func probeGIF(b []byte, info *ImageInfo) error {
+	if len(b) < 13 {
+		return fmt.Errorf("%w: missing GIF header", ErrInvalidImage)
+	}
+	info.Width = int(binary.LittleEndian.Uint16(b[6:8]))
+	info.Height = int(binary.LittleEndian.Uint16(b[8:10]))
+
+	off := 13
+	if flags := b[10]; flags&0x80 != 0 {
+		off += 3 << ((flags & 0x07) + 1)
+	}
+
+	// skipSubBlocks returns the offset after the data sub-blocks starting at i.
+	skipSubBlocks := func(i int) int {
+		for i < len(b) && b[i] != 0 {
+			i += int(b[i]) + 1
+		}
+		return i + 1
+	}
+
+	frames := 0
+	for off < len(b) {
+		switch b[off] {
+		case 0x21: // Extension
+			if off+2 >= len(b) {
+				return nil
+			}
+			if b[off+1] == 0xff && bytes.HasPrefix(b[off+2:], []byte("\x0bNETSCAPE2.0")) {
+				info.Animated = true
+				return nil
+			}
+			off = skipSubBlocks(off + 2)
+		case 0x2c: // Image descriptor
+			if frames++; frames > 1 {
+				info.Animated = true
+				return nil
+			}
+			if off+10 > len(b) {
+				return nil
+			}
+			flags := b[off+9]
+			off += 10
+			if flags&0x80 != 0 {
+				off += 3 << ((flags & 0x07) + 1)
+			}
+			// LZW minimum code size, then the image data.
+			off = skipSubBlocks(off + 1)
+		default: // Trailer or unknown block
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// probeWebP reads the first chunk: lossy (VP8), lossless (VP8L) or extended (VP8X).
+func probeWebP(b []byte, info *ImageInfo) error {
+	if len(b) < 30 || string(b[0:4]) != "RIFF" || string(b[8:12]) != "WEBP" {
+		return fmt.Errorf("%w: missing WebP header", ErrInvalidImage)
+	}
+
+	data := b[20:]
+	switch string(b[12:16]) {
+	case "VP8 ":
+		if data[3] != 0x9d || data[4] != 0x01 || data[5] != 0x2a {
+			return fmt.Errorf("%w: invalid VP8 frame", ErrInvalidImage)
+		}
+		info.Width = int(binary.LittleEndian.Uint16(data[6:8]) & 0x3fff)
+		info.Height = int(binary.LittleEndian.Uint16(data[8:10]) & 0x3fff)
+	case "VP8L":
+		if data[0] != 0x2f {
+			return fmt.Errorf("%w: invalid VP8L signature", ErrInvalidImage)
+		}
+		bits := binary.LittleEndian.Uint32(data[1:5])
+		info.Width = int(bits&0x3fff) + 1
+		info.Height = int((bits>>14)&0x3fff) + 1
+	case "VP8X":
+		flags := data[0]
+		info.Animated = flags&0x02 != 0
+		info.Width = int(uint32(data[4])|uint32(data[5])<<8|uint32(data[6])<<16) + 1
+		info.Height = int(uint32(data[7])|uint32(data[8])<<8|uint32(data[9])<<16) + 1
+		if flags&0x08 != 0 {
+			info.Orientation = webpExifOrientation(b)
+		}
+	default:
+		return fmt.Errorf("%w: unknown WebP chunk %q", ErrInvalidImage, b[12:16])
+	}
+
+	return nil
+}
+
+// webpExifOrientation looks for the EXIF chunk and returns the orientation, 1 if not found.
+func webpExifOrientation(b []byte) int {
+	for off := 12; off+8 <= len(b); {
+		size := int(binary.LittleEndian.Uint32(b[off+4 : off+8]))
+		if size < 0 || size > len(b) {
+			break
+		}
+		if string(b[off:off+4]) == "EXIF" {
+			data := b[off+8 : minInt(off+8+size, len(b))]
+			// Some encoders keep the JPEG APP1 prefix.
+			data = bytes.TrimPrefix(data, []byte("Exif\x00\x00"))
+			if o := exifOrientation(data); o > 0 {
+				return o
+			}
+			break
+		}
+		// Chunks are padded to an even size.
+		off += 8 + size + size%2
+	}
+	return 1
+}
+
+// probeBMP reads the dimensions from the DIB header, the height is negative for top-down bitmaps.
+func probeBMP(b []byte, info *ImageInfo) error {
+	if len(b) < 26 {
+		return fmt.Errorf("%w: missing BMP header", ErrInvalidImage)
+	}
+
+	if binary.LittleEndian.Uint32(b[14:18]) == 12 {
+		// OS/2 BITMAPCOREHEADER with 16-bit dimensions.
+		info.Width = int(binary.LittleEndian.Uint16(b[18:20]))
+		info.Height = int(binary.LittleEndian.Uint16(b[20:22]))
+		return nil
+	}
+
+	info.Width = int(int32(binary.LittleEndian.Uint32(b[18:22])))
+	info.Height = int(int32(binary.LittleEndian.Uint32(b[22:26])))
+	if info.Height < 0 {
+		info.Height = -info.Height
+	}
+
+	return nil
+}
+
+// exifOrientation returns the orientation from the TIFF structure of the EXIF data, 0 if not found.
+func exifOrientation(b []byte) int {
+	if len(b) < 8 {
+		return 0
+	}
+
+	var order binary.ByteOrder
+	switch string(b[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+	if order.Uint16(b[2:4]) != 42 {
+		return 0
+	}
+
+	ifd := int(order.Uint32(b[4:8]))
+	if ifd < 8 || ifd+2 > len(b) {
+		return 0
+	}
+
+	count := int(order.Uint16(b[ifd : ifd+2]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(b) {
+			return 0
+		}
+		if order.Uint16(b[entry:entry+2]) != exifOrientationTag {
+			continue
+		}
+		// SHORT value stored in the first two bytes of the value field.
+		if o := int(order.Uint16(b[entry+8 : entry+10])); o >= 1 && o <= 8 {
+			return o
+		}
+		return 0
+	}
+
+	return 0
+}
@@ -0,0 +1,241 @@
+package utils_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dmitrymomot/go-utils"
+)
+
+func TestProbeImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 30))
+
+	var pngBuf bytes.Buffer
+	if err := png.Encode(&pngBuf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	var jpegBuf bytes.Buffer
+	if err := jpeg.Encode(&jpegBuf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	palette := color.Palette{color.Black, color.White}
+	frame := image.NewPaletted(image.Rect(0, 0, 20, 10), palette)
+	var gifBuf, animatedGIFBuf bytes.Buffer
+	if err := gif.Encode(&gifBuf, frame, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := gif.EncodeAll(&animatedGIFBuf, &gif.GIF{
+		Image: []*image.Paletted{frame, frame},
+		Delay: []int{10, 10},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		data        []byte
+		format      string
+		contentType string
+		width       int
+		height      int
+		orientation int
+		animated    bool
+	}{
+		{"png", pngBuf.Bytes(), utils.ImageFormatPNG, "image/png", 40, 30, 1, false},
+		{"apng", apngImage(pngBuf.Bytes(), 3), utils.ImageFormatPNG, "image/vnd.mozilla.apng", 40, 30, 1, true},
+		{"jpeg", jpegBuf.Bytes(), utils.ImageFormatJPEG, "image/jpeg", 40, 30, 1, false},
+		{"jpeg with exif", jpegWithOrientation(jpegBuf.Bytes(), 6, binary.BigEndian), utils.ImageFormatJPEG, "image/jpeg", 40, 30, 6, false},
+		{"jpeg with little endian exif", jpegWithOrientation(jpegBuf.Bytes(), 3, binary.LittleEndian), utils.ImageFormatJPEG, "image/jpeg", 40, 30, 3, false},
+		{"gif", gifBuf.Bytes(), utils.ImageFormatGIF, "image/gif", 20, 10, 1, false},
+		{"animated gif", animatedGIFBuf.Bytes(), utils.ImageFormatGIF, "image/gif", 20, 10, 1, true},
+		{"webp lossless", webpLossless(300, 200), utils.ImageFormatWebP, "image/webp", 300, 200, 1, false},
+		{"webp extended animated", webpExtended(1000, 500, true, 0), utils.ImageFormatWebP, "image/webp", 1000, 500, 1, true},
+		{"webp extended with exif", webpExtended(64, 48, false, 8), utils.ImageFormatWebP, "image/webp", 64, 48, 8, false},
+		{"bmp", bmpImage(17, -9), utils.ImageFormatBMP, "image/bmp", 17, 9, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := utils.ProbeImage(bytes.NewReader(tt.data))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			expected := utils.ImageInfo{
+				Format:      tt.format,
+				ContentType: tt.contentType,
+				Width:       tt.width,
+				Height:      tt.height,
+				Orientation: tt.orientation,
+				Animated:    tt.animated,
+			}
+			if info != expected {
+				t.Errorf("got %+v, want %+v", info, expected)
+			}
+		})
+	}
+
+	t.Run("display size", func(t *testing.T) {
+		info, err := utils.ProbeImage(bytes.NewReader(jpegWithOrientation(jpegBuf.Bytes(), 6, binary.BigEndian)))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if w, h := info.DisplaySize(); w != 30 || h != 40 {
+			t.Errorf("got %dx%d, want 30x40", w, h)
+		}
+	})
+
+	t.Run("budget", func(t *testing.T) {
+		// The frame header is behind a large comment segment.
+		comment := append([]byte{0xff, 0xfe, 0xff, 0xff}, make([]byte, 0xfffd)...)
+		data := append(append([]byte{0xff, 0xd8}, comment...), jpegBuf.Bytes()[2:]...)
+
+		if _, err := utils.ProbeImage(bytes.NewReader(data), utils.WithImageProbeBudget(1024)); !errors.Is(err, utils.ErrInvalidImage) {
+			t.Errorf("got error %v, want %v", err, utils.ErrInvalidImage)
+		}
+		if info, err := utils.ProbeImage(bytes.NewReader(data)); err != nil || info.Width != 40 {
+			t.Errorf("got %+v, %v", info, err)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		if _, err := utils.ProbeImage(strings.NewReader("<html></html>")); !errors.Is(err, utils.ErrUnsupportedImage) {
+			t.Errorf("got error %v, want %v", err, utils.ErrUnsupportedImage)
+		}
+		if _, err := utils.ProbeImage(bytes.NewReader(pngBuf.Bytes()[:20])); !errors.Is(err, utils.ErrInvalidImage) {
+			t.Errorf("got error %v, want %v", err, utils.ErrInvalidImage)
+		}
+		if _, err := utils.ProbeImage(strings.NewReader("")); !errors.Is(err, utils.ErrEmptyInput) {
+			t.Errorf("got error %v, want %v", err, utils.ErrEmptyInput)
+		}
+		if _, err := utils.ProbeImage(nil); !errors.Is(err, utils.ErrInvalidReader) {
+			t.Errorf("got error %v, want %v", err, utils.ErrInvalidReader)
+		}
+	})
+}
+
+func TestProbeImageFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "image.bmp")
+	if err := os.WriteFile(path, bmpImage(8, 4), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := utils.ProbeImageFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.Width != 8 || info.Height != 4 {
+		t.Errorf("got %dx%d, want 8x4", info.Width, info.Height)
+	}
+}
+
+// apngImage inserts the animation control chunk after the IHDR chunk.
+func apngImage(pngData []byte, frames uint32) []byte {
+	chunk := make([]byte, 20)
+	binary.BigEndian.PutUint32(chunk[0:4], 8)
+	copy(chunk[4:8], "acTL")
+	binary.BigEndian.PutUint32(chunk[8:12], frames)
+
+	const ihdrEnd = 33
+	return append(append(append([]byte{}, pngData[:ihdrEnd]...), chunk...), pngData[ihdrEnd:]...)
+}
+
+// jpegWithOrientation inserts the EXIF segment with the orientation tag after the SOI marker.
+func jpegWithOrientation(jpegData []byte, orientation uint16, order binary.ByteOrder) []byte {
+	tiff := make([]byte, 26)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:4], 42)
+	order.PutUint32(tiff[4:8], 8)
+	order.PutUint16(tiff[8:10], 1)
+	order.PutUint16(tiff[10:12], 0x0112)
+	order.PutUint16(tiff[12:14], 3)
+	order.PutUint32(tiff[14:18], 1)
+	order.PutUint16(tiff[18:20], orientation)
+
+	data := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xff, 0xe1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:4], uint16(len(data)+2))
+	segment = append(segment, data...)
+
+	return append(append(append([]byte{}, jpegData[:2]...), segment...), jpegData[2:]...)
+}
+
+// webpLossless returns the header of a lossless WebP image.
+func webpLossless(width, height uint32) []byte {
+	data := make([]byte, 10)
+	data[0] = 0x2f
+	binary.LittleEndian.PutUint32(data[1:5], (width-1)|(height-1)<<14)
+	return riffWebP(webpChunk("VP8L", data))
+}
+
+// webpExtended returns the header of an extended WebP image, orientation 0 means no EXIF chunk.
+func webpExtended(width, height uint32, animated bool, orientation uint16) []byte {
+	data := make([]byte, 10)
+	if animated {
+		data[0] |= 0x02
+	}
+	if orientation > 0 {
+		data[0] |= 0x08
+	}
+	w, h := width-1, height-1
+	data[4], data[5], data[6] = byte(w), byte(w>>8), byte(w>>16)
+	data[7], data[8], data[9] = byte(h), byte(h>>8), byte(h>>16)
+
+	chunks := webpChunk("VP8X", data)
+	chunks = append(chunks, webpChunk("ICCP", []byte{1, 2, 3})...)
+	if orientation > 0 {
+		// Keep the "Exif\x00\x00" prefix some encoders write.
+		exif := jpegWithOrientation([]byte{0xff, 0xd8}, orientation, binary.LittleEndian)[6:]
+		chunks = append(chunks, webpChunk("EXIF", exif)...)
+	}
+	return riffWebP(chunks)
+}
+
+func webpChunk(fourcc string, data []byte) []byte {
+	chunk := make([]byte, 8, 8+len(data)+1)
+	copy(chunk, fourcc)
+	binary.LittleEndian.PutUint32(chunk[4:8], uint32(len(data)))
+	chunk = append(chunk, data...)
+	if len(data)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+func riffWebP(chunks []byte) []byte {
+	header := make([]byte, 12)
+	copy(header, "RIFF")
+	binary.LittleEndian.PutUint32(header[4:8], uint32(4+len(chunks)))
+	copy(header[8:12], "WEBP")
+	return append(header, chunks...)
+}
+
+// bmpImage returns the headers of a BMP image.
+func bmpImage(width, height int32) []byte {
+	b := make([]byte, 54)
+	copy(b, "BM")
+	binary.LittleEndian.PutUint32(b[2:6], 54)
+	binary.LittleEndian.PutUint32(b[10:14], 54)
+	binary.LittleEndian.PutUint32(b[14:18], 40)
+	binary.LittleEndian.PutUint32(b[18:22], uint32(width))
+	binary.LittleEndian.PutUint32(b[22:26], uint32(height))
+	binary.LittleEndian.PutUint16(b[26:28], 1)
+	binary.LittleEndian.PutUint16(b[28:30], 24)
+	return b
+}
@@ -0,0 +1,168 @@
+package utils
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+	"sync"
+)
+
+// mimeTypesList is the embedded table of media types and extensions.
+//
+//go:embed data/mime_types.txt
+var mimeTypesList string
+
+// DefaultMimeRegistry is the registry used by GetFileTypeByURI and the package level helpers.
+var DefaultMimeRegistry = NewDefaultMimeRegistry()
+
+// MimeRegistry maps file extensions to media types and back.
+// Lookups are case-insensitive, it's safe for concurrent use.
+type MimeRegistry struct {
+	mu     sync.RWMutex
+	byExt  map[string]string
+	byType map[string][]string
+}
+
+// NewMimeRegistry returns an empty registry.
+func NewMimeRegistry() *MimeRegistry {
+	return &MimeRegistry{
+		byExt:  make(map[string]string),
+		byType: make(map[string][]string),
+	}
+}
+
+// NewDefaultMimeRegistry returns a registry seeded with the embedded table of common media types.
+func NewDefaultMimeRegistry() *MimeRegistry {
+	r := NewMimeRegistry()
+	// The embedded table is a plain string, so reading it never fails.
+	_ = r.Load(strings.NewReader(mimeTypesList))
+	return r
+}
+
+// Register maps the extensions to the media type. Extensions may have a leading dot.
+// The extensions take precedence over previous registrations for the extension lookup.
+// The first extension registered for a media type is the preferred one, see ExtensionByType.
+func (r *MimeRegistry) Register(mimeType string, exts ...string) {
+	mimeType = normalizeMimeType(mimeType)
+	if mimeType == "" {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, ext := range exts {
+		ext = normalizeExtension(ext)
+		if ext == "" {
+			continue
+		}
+
+		r.byExt[ext] = mimeType
+
+		exists := false
+		for _, e := range r.byType[mimeType] {
+			if e == ext {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			r.byType[mimeType] = append(r.byType[mimeType], ext)
+		}
+	}
+}
+
+// Load reads additional media types from the reader.
+// Each line contains a media type followed by its extensions separated by spaces,
+// e.g. "image/jpeg jpg jpeg". Empty lines and lines starting with "#" are ignored.
+func (r *MimeRegistry) Load(rd io.Reader) error {
+	lines, err := readListLines(rd)
+	if err != nil {
+		return fmt.Errorf("failed to load mime types: %w", err)
+	}
+
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return fmt.Errorf("failed to load mime types: invalid line %q", line)
+		}
+		r.Register(fields[0], fields[1:]...)
+	}
+
+	return nil
+}
+
+// TypeByExtension returns the media type of the extension, e.g. ".png" or "PNG" -> "image/png".
+// The second return value is false if the extension is unknown.
+func (r *MimeRegistry) TypeByExtension(ext string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, ok := r.byExt[normalizeExtension(ext)]
+	return t, ok
+}
+
+// ExtensionsByType returns all extensions of the media type with a leading dot,
+// the preferred extension goes first. Media type parameters are ignored.
+func (r *MimeRegistry) ExtensionsByType(mimeType string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	exts := r.byType[normalizeMimeType(mimeType)]
+	result := make([]string, 0, len(exts))
+	for _, ext := range exts {
+		result = append(result, "."+ext)
+	}
+
+	return result
+}
+
+// ExtensionByType returns the preferred extension of the media type with a leading dot,
+// e.g. "image/jpeg" -> ".jpg". The second return value is false if the media type is unknown.
+func (r *MimeRegistry) ExtensionByType(mimeType string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	exts := r.byType[normalizeMimeType(mimeType)]
+	if len(exts) == 0 {
+		return "", false
+	}
+
+	return "." + exts[0], true
+}
+
+// RegisterMimeType maps the extensions to the media type in the default registry.
+func RegisterMimeType(mimeType string, exts ...string) {
+	DefaultMimeRegistry.Register(mimeType, exts...)
+}
+
+// GetMimeTypeByExtension returns the media type of the extension,
+// or "application/octet-stream" if the extension is unknown.
+func GetMimeTypeByExtension(ext string) string {
+	if t, ok := DefaultMimeRegistry.TypeByExtension(ext); ok {
+		return t
+	}
+	return defaultMimeType
+}
+
+// GetExtensionByMimeType returns the preferred file extension of the media type with a leading dot,
+// or an empty string if the media type is unknown.
+func GetExtensionByMimeType(mimeType string) string {
+	ext, _ := DefaultMimeRegistry.ExtensionByType(mimeType)
+	return ext
+}
+
+// normalizeExtension lowercases the extension and removes the leading dot.
+func normalizeExtension(ext string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), "."))
+}
+
+// normalizeMimeType lowercases the media type and removes its parameters.
+func normalizeMimeType(mimeType string) string {
+	if t, _, err := mime.ParseMediaType(mimeType); err == nil {
+		return t
+	}
+	return strings.ToLower(strings.TrimSpace(strings.Split(mimeType, ";")[0]))
+}
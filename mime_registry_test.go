@@ -0,0 +1,109 @@
+package utils_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dmitrymomot/go-utils"
+)
+
+func TestGetMimeTypeByExtension(t *testing.T) {
+	tests := []struct {
+		ext  string
+		want string
+	}{
+		{"png", "image/png"},
+		{".PNG", "image/png"},
+		{"jpeg", "image/jpeg"},
+		{"webm", "video/webm"},
+		{"mkv", "video/x-matroska"},
+		{"js", "application/javascript"},
+		{"xml", "application/xml"},
+		{"ico", "image/x-icon"},
+		{"md", "text/markdown"},
+		{"wav", "audio/wav"},
+		{"xlsx", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+		{"unknown", "application/octet-stream"},
+		{"", "application/octet-stream"},
+	}
+	for _, tt := range tests {
+		if got := utils.GetMimeTypeByExtension(tt.ext); got != tt.want {
+			t.Errorf("GetMimeTypeByExtension(%q) = %v, want %v", tt.ext, got, tt.want)
+		}
+	}
+}
+
+func TestGetExtensionByMimeType(t *testing.T) {
+	tests := []struct {
+		mimeType string
+		want     string
+	}{
+		{"image/jpeg", ".jpg"},
+		{"IMAGE/JPEG", ".jpg"},
+		{"image/jpg", ".jpg"},
+		{"text/plain; charset=utf-8", ".txt"},
+		{"video/x-matroska", ".mkv"},
+		{"application/gzip", ".gz"},
+		{"application/x-unknown", ""},
+	}
+	for _, tt := range tests {
+		if got := utils.GetExtensionByMimeType(tt.mimeType); got != tt.want {
+			t.Errorf("GetExtensionByMimeType(%q) = %v, want %v", tt.mimeType, got, tt.want)
+		}
+	}
+}
+
+func TestMimeRegistry(t *testing.T) {
+	t.Run("register", func(t *testing.T) {
+		r := utils.NewMimeRegistry()
+		r.Register("Application/X-Custom", ".cst", "CUSTOM", "cst")
+
+		if got, ok := r.TypeByExtension("custom"); !ok || got != "application/x-custom" {
+			t.Errorf("TypeByExtension() = %v, %v", got, ok)
+		}
+		if got := r.ExtensionsByType("application/x-custom"); !reflect.DeepEqual(got, []string{".cst", ".custom"}) {
+			t.Errorf("ExtensionsByType() = %v", got)
+		}
+		if got, ok := r.ExtensionByType("application/x-custom"); !ok || got != ".cst" {
+			t.Errorf("ExtensionByType() = %v, %v", got, ok)
+		}
+	})
+
+	t.Run("override", func(t *testing.T) {
+		r := utils.NewDefaultMimeRegistry()
+		r.Register("text/x-typescript", "ts")
+
+		if got, _ := r.TypeByExtension("ts"); got != "text/x-typescript" {
+			t.Errorf("TypeByExtension() = %v, want text/x-typescript", got)
+		}
+		// The previous type keeps the extension for the reverse lookup.
+		if got := r.ExtensionsByType("video/mp2t"); !reflect.DeepEqual(got, []string{".ts"}) {
+			t.Errorf("ExtensionsByType() = %v", got)
+		}
+		// The default registry is not affected.
+		if got := utils.GetMimeTypeByExtension("ts"); got != "video/mp2t" {
+			t.Errorf("GetMimeTypeByExtension() = %v, want video/mp2t", got)
+		}
+	})
+
+	t.Run("load", func(t *testing.T) {
+		r := utils.NewMimeRegistry()
+		if err := r.Load(strings.NewReader("# comment\n\nimage/x-foo foo fo\n")); err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if got, _ := r.TypeByExtension("fo"); got != "image/x-foo" {
+			t.Errorf("TypeByExtension() = %v, want image/x-foo", got)
+		}
+		if err := r.Load(strings.NewReader("image/x-bar\n")); err == nil {
+			t.Error("expected error but got none")
+		}
+	})
+
+	t.Run("register in default registry", func(t *testing.T) {
+		utils.RegisterMimeType("application/x-test-utils", "testutils")
+		if got := utils.GetFileTypeByURI("https://example.com/file.TESTUTILS"); got != "application/x-test-utils" {
+			t.Errorf("GetFileTypeByURI() = %v, want application/x-test-utils", got)
+		}
+	})
+}
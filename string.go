@@ -3,9 +3,6 @@ package utils
 import (
 	"strings"
 	"unicode"
-
-	"github.com/labstack/gommon/bytes"
-	"github.com/pkg/errors"
 )
 
 // Trim string between two substrings and return the string without it and substrings.
@@ -23,16 +20,6 @@ func TrimRightZeros(str string) string {
 	return strings.TrimRight(str, "0")
 }
 
-// Parse file size from string to int64
-func ParseFileSize(s string) (int64, error) {
-	size, err := bytes.Parse(s)
-	if err != nil {
-		return 0, errors.Wrap(err, "failed to parse file size")
-	}
-
-	return size, nil
-}
-
 // UcFirst capitalizes first letter of a string
 func UcFirst(s string) string {
 	if len(s) == 0 {
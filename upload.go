@@ -0,0 +1,283 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"path/filepath"
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// UploadIssueCode identifies a problem found by UploadPolicy.Validate.
+type UploadIssueCode string
+
+// Predefined upload issue codes
+const (
+	UploadIssueEmpty             UploadIssueCode = "empty"
+	UploadIssueTooLarge          UploadIssueCode = "too_large"
+	UploadIssueTypeNotAllowed    UploadIssueCode = "type_not_allowed"
+	UploadIssueDeclaredMismatch  UploadIssueCode = "declared_type_mismatch"
+	UploadIssueExtensionMismatch UploadIssueCode = "extension_mismatch"
+)
+
+type (
+	// UploadPolicy describes which uploaded files are accepted.
+	UploadPolicy struct {
+		// AllowedTypes is a list of allowed media types, e.g. "image/png",
+		// or families, e.g. "image/*". Empty list allows any type.
+		AllowedTypes []string
+		// MaxSize is the max file size in bytes, zero means no limit.
+		MaxSize int64
+	}
+
+	// UploadIssue describes a single problem of an uploaded file.
+	UploadIssue struct {
+		// Code is a machine readable issue code.
+		Code UploadIssueCode
+		// Message is a human readable description of the issue.
+		Message string
+		// Err is the matching predefined error, e.g. ErrFileTooLarge.
+		Err error
+	}
+
+	// UploadVerdict is the result of UploadPolicy.Validate.
+	UploadVerdict struct {
+		// ContentType is the effective media type of the file: the sniffed type,
+		// or the extension type if it's a more specific variant of the sniffed one (e.g. text/markdown for text/plain).
+		ContentType string
+		// DeclaredType is the media type declared by the client, without parameters.
+		DeclaredType string
+		// ExtensionType is the media type of the file name extension.
+		ExtensionType string
+		// DetectedType is the media type sniffed from the file content (magic bytes).
+		DetectedType string
+		// Size is the file size in bytes. If the file exceeds the max size, it's the number of bytes read.
+		Size int64
+		// Issues is the list of problems found, empty if the file is accepted.
+		Issues []UploadIssue
+	}
+)
+
+// Error implements the error interface.
+func (i UploadIssue) Error() string {
+	return i.Message
+}
+
+// Unwrap returns the matching predefined error, so errors.Is can be used with the issue.
+func (i UploadIssue) Unwrap() error {
+	return i.Err
+}
+
+// Allowed reports whether the file is accepted by the policy.
+func (v UploadVerdict) Allowed() bool {
+	return len(v.Issues) == 0
+}
+
+// Has reports whether the verdict contains an issue with the given code.
+func (v UploadVerdict) Has(code UploadIssueCode) bool {
+	for _, i := range v.Issues {
+		if i.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Err returns the first issue as an error or nil if the file is accepted.
+func (v UploadVerdict) Err() error {
+	if len(v.Issues) == 0 {
+		return nil
+	}
+	return v.Issues[0]
+}
+
+// Validate checks the uploaded file content against the policy.
+// It compares the declared content type and the file name extension with the type
+// sniffed from the content, so an ".jpg" file which is actually HTML is rejected.
+// If r is an io.ReadSeeker, its read position is reset to the beginning afterwards,
+// otherwise the reader is consumed up to MaxSize+1 bytes.
+// The returned error is not nil only if the content can't be read.
+func (p UploadPolicy) Validate(r io.Reader, filename, declaredType string) (UploadVerdict, error) {
+	if r == nil {
+		return UploadVerdict{}, fmt.Errorf("invalid reader: %w", ErrInvalidReader)
+	}
+
+	v := UploadVerdict{DeclaredType: normalizeMimeType(declaredType)}
+	if ext := filepath.Ext(filename); ext != "" {
+		v.ExtensionType, _ = DefaultMimeRegistry.TypeByExtension(ext)
+	}
+
+	head := make([]byte, fileStreamSniffLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return v, fmt.Errorf("failed to read file: %w", err)
+	}
+	head = head[:n]
+
+	v.Size = int64(n)
+	if rs, ok := r.(io.ReadSeeker); ok {
+		if v.Size, err = rs.Seek(0, io.SeekEnd); err != nil {
+			return v, fmt.Errorf("failed to get file size: %w", err)
+		}
+		if _, err := rs.Seek(0, io.SeekStart); err != nil {
+			return v, fmt.Errorf("failed to reset read position: %w", err)
+		}
+	} else if n == len(head) {
+		var rest io.Reader = r
+		if p.MaxSize > 0 {
+			rest = io.LimitReader(r, p.MaxSize+1-v.Size)
+		}
+		m, err := io.Copy(io.Discard, rest)
+		if err != nil {
+			return v, fmt.Errorf("failed to read file: %w", err)
+		}
+		v.Size += m
+	}
+
+	if v.Size == 0 {
+		v.Issues = append(v.Issues, UploadIssue{
+			Code:    UploadIssueEmpty,
+			Message: "file is empty",
+			Err:     ErrEmptyInput,
+		})
+		return v, nil
+	}
+
+	if p.MaxSize > 0 && v.Size > p.MaxSize {
+		v.Issues = append(v.Issues, UploadIssue{
+			Code:    UploadIssueTooLarge,
+			Message: fmt.Sprintf("file must be at most %d bytes", p.MaxSize),
+			Err:     ErrFileTooLarge,
+		})
+	}
+
+	v.DetectedType, _ = GetFileContentTypeByBytes(head)
+	v.ContentType = v.DetectedType
+
+	if v.ExtensionType != "" && v.ExtensionType != defaultMimeType {
+		if !mimeTypesCompatible(v.ExtensionType, v.DetectedType) {
+			v.Issues = append(v.Issues, UploadIssue{
+				Code:    UploadIssueExtensionMismatch,
+				Message: fmt.Sprintf("file extension %q doesn't match the content type %s", filepath.Ext(filename), v.DetectedType),
+				Err:     ErrUploadTypeMismatch,
+			})
+		} else if v.ExtensionType != v.DetectedType && isGenericMimeType(v.DetectedType) {
+			v.ContentType = v.ExtensionType
+		}
+	}
+
+	if v.DeclaredType != "" && v.DeclaredType != defaultMimeType && !mimeTypesCompatible(v.DeclaredType, v.DetectedType) {
+		v.Issues = append(v.Issues, UploadIssue{
+			Code:    UploadIssueDeclaredMismatch,
+			Message: fmt.Sprintf("declared content type %s doesn't match the content type %s", v.DeclaredType, v.DetectedType),
+			Err:     ErrUploadTypeMismatch,
+		})
+	}
+
+	if !mimeTypeMatches(p.AllowedTypes, v.ContentType) {
+		v.Issues = append(v.Issues, UploadIssue{
+			Code:    UploadIssueTypeNotAllowed,
+			Message: fmt.Sprintf("file type %s is not allowed", v.ContentType),
+			Err:     ErrUploadTypeNotAllowed,
+		})
+	}
+
+	return v, nil
+}
+
+// ValidateFileHeader checks the multipart form file against the policy, see Validate.
+func (p UploadPolicy) ValidateFileHeader(fh *multipart.FileHeader) (UploadVerdict, error) {
+	if fh == nil {
+		return UploadVerdict{}, fmt.Errorf("invalid file: %w", ErrInvalidReader)
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		return UploadVerdict{}, fmt.Errorf("failed to open uploaded file: %w", err)
+	}
+	defer f.Close()
+
+	return p.Validate(f, fh.Filename, fh.Header.Get("Content-Type"))
+}
+
+// mimeTypeMatches reports whether the media type matches the allowlist,
+// which may contain families like "image/*". An empty allowlist matches any type.
+func mimeTypeMatches(allowlist []string, mimeType string) bool {
+	if len(allowlist) == 0 {
+		return true
+	}
+
+	for _, allowed := range allowlist {
+		allowed = normalizeMimeType(allowed)
+		if family := strings.TrimSuffix(allowed, "/*"); family != allowed {
+			if strings.HasPrefix(mimeType, family+"/") {
+				return true
+			}
+			continue
+		}
+		if allowed == mimeType {
+			return true
+		}
+		if m := mimetype.Lookup(mimeType); m != nil && m.Is(allowed) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mimeTypesCompatible reports whether the claimed media type is consistent with the detected one:
+// they are equal, aliases, or the detected type is a generic parent of the claimed type.
+func mimeTypesCompatible(claimed, detected string) bool {
+	if claimed == detected {
+		return true
+	}
+
+	if m := mimetype.Lookup(detected); m != nil && m.Is(claimed) {
+		return true
+	}
+
+	// The detected type is a parent of the claimed one, e.g. application/zip for docx.
+	// Every type descends from application/octet-stream, it's handled below.
+	for m := mimetype.Lookup(claimed); m != nil && detected != defaultMimeType; m = m.Parent() {
+		if m.Is(detected) {
+			return true
+		}
+	}
+
+	switch detected {
+	case "text/plain":
+		// Text formats without magic bytes, e.g. text/markdown or application/x-yaml.
+		return isTextMimeType(claimed)
+	case defaultMimeType:
+		// Binary formats unknown to the content sniffer.
+		return mimetype.Lookup(claimed) == nil && !isTextMimeType(claimed)
+	}
+
+	return false
+}
+
+// isGenericMimeType reports whether the detected type carries no specific format information.
+func isGenericMimeType(mimeType string) bool {
+	return mimeType == "text/plain" || mimeType == defaultMimeType
+}
+
+// isTextMimeType reports whether the media type is a text based format.
+func isTextMimeType(mimeType string) bool {
+	if strings.HasPrefix(mimeType, "text/") ||
+		strings.HasSuffix(mimeType, "+json") ||
+		strings.HasSuffix(mimeType, "+xml") {
+		return true
+	}
+
+	switch mimeType {
+	case "application/json", "application/javascript", "application/xml",
+		"application/toml", "application/graphql", "application/sql",
+		"application/x-pem-file", "application/pgp-signature":
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,221 @@
+package utils_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dmitrymomot/go-utils"
+)
+
+func TestUploadPolicyValidate(t *testing.T) {
+	png := "\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 100)
+	jpeg := "\xff\xd8\xff\xe0\x00\x10JFIF\x00" + strings.Repeat("\x00", 100)
+	html := "<!DOCTYPE html><html><body>hello</body></html>"
+	binary := strings.Repeat("\x00\xff\x13\x88\x7f\x01", 100)
+
+	policy := utils.UploadPolicy{
+		AllowedTypes: []string{"image/*", "application/pdf", "text/markdown"},
+		MaxSize:      1024,
+	}
+
+	tests := []struct {
+		name        string
+		content     string
+		filename    string
+		declared    string
+		contentType string
+		issues      []utils.UploadIssueCode
+	}{
+		{
+			name:        "valid image",
+			content:     png,
+			filename:    "photo.png",
+			declared:    "image/png",
+			contentType: "image/png",
+		},
+		{
+			name:        "declared type with parameters",
+			content:     jpeg,
+			filename:    "photo.JPG",
+			declared:    "image/jpeg; charset=binary",
+			contentType: "image/jpeg",
+		},
+		{
+			name:        "generic declared type is ignored",
+			content:     png,
+			filename:    "photo.png",
+			declared:    "application/octet-stream",
+			contentType: "image/png",
+		},
+		{
+			name:        "text format refined by extension",
+			content:     "# Title\n\nSome text.\n",
+			filename:    "README.md",
+			declared:    "text/markdown",
+			contentType: "text/markdown",
+		},
+		{
+			name:        "html disguised as jpeg",
+			content:     html,
+			filename:    "photo.jpg",
+			declared:    "image/jpeg",
+			contentType: "text/html",
+			issues: []utils.UploadIssueCode{
+				utils.UploadIssueExtensionMismatch,
+				utils.UploadIssueDeclaredMismatch,
+				utils.UploadIssueTypeNotAllowed,
+			},
+		},
+		{
+			name:        "binary disguised as jpeg",
+			content:     binary,
+			filename:    "photo.jpg",
+			declared:    "image/jpeg",
+			contentType: "application/octet-stream",
+			issues: []utils.UploadIssueCode{
+				utils.UploadIssueExtensionMismatch,
+				utils.UploadIssueDeclaredMismatch,
+				utils.UploadIssueTypeNotAllowed,
+			},
+		},
+		{
+			name:        "binary disguised as pdf",
+			content:     binary,
+			filename:    "doc.pdf",
+			contentType: "application/octet-stream",
+			issues: []utils.UploadIssueCode{
+				utils.UploadIssueExtensionMismatch,
+				utils.UploadIssueTypeNotAllowed,
+			},
+		},
+		{
+			name:        "wrong extension",
+			content:     png,
+			filename:    "photo.jpg",
+			contentType: "image/png",
+			issues:      []utils.UploadIssueCode{utils.UploadIssueExtensionMismatch},
+		},
+		{
+			name:        "pdf with archive extension",
+			content:     "%PDF-1.4\n" + strings.Repeat("x", 100),
+			filename:    "doc.zip",
+			contentType: "application/pdf",
+			issues:      []utils.UploadIssueCode{utils.UploadIssueExtensionMismatch},
+		},
+		{
+			name:        "too large",
+			content:     png + strings.Repeat("\x00", 1024),
+			filename:    "photo.png",
+			contentType: "image/png",
+			issues:      []utils.UploadIssueCode{utils.UploadIssueTooLarge},
+		},
+		{
+			name:     "empty",
+			filename: "photo.png",
+			issues:   []utils.UploadIssueCode{utils.UploadIssueEmpty},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Plain reader without Seek support.
+			r := struct{ io.Reader }{strings.NewReader(tt.content)}
+			v, err := policy.Validate(r, tt.filename, tt.declared)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if v.ContentType != tt.contentType {
+				t.Errorf("got content type %q, want %q", v.ContentType, tt.contentType)
+			}
+			if len(v.Issues) != len(tt.issues) {
+				t.Fatalf("got issues %+v, want %v", v.Issues, tt.issues)
+			}
+			for _, code := range tt.issues {
+				if !v.Has(code) {
+					t.Errorf("missing issue %q in %+v", code, v.Issues)
+				}
+			}
+			if v.Allowed() != (len(tt.issues) == 0) {
+				t.Errorf("got allowed %v", v.Allowed())
+			}
+		})
+	}
+}
+
+func TestUploadPolicyValidate_Verdict(t *testing.T) {
+	html := "<html><body>" + strings.Repeat("x", 4000) + "</body></html>"
+	r := strings.NewReader(html)
+
+	v, err := utils.UploadPolicy{AllowedTypes: []string{"image/jpeg"}}.Validate(r, "cat.jpg", "image/jpeg")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if v.DeclaredType != "image/jpeg" || v.ExtensionType != "image/jpeg" || v.DetectedType != "text/html" {
+		t.Errorf("unexpected verdict: %+v", v)
+	}
+	if v.Size != int64(len(html)) {
+		t.Errorf("got size %d, want %d", v.Size, len(html))
+	}
+	if err := v.Err(); !errors.Is(err, utils.ErrUploadTypeMismatch) {
+		t.Errorf("got error %v, want %v", err, utils.ErrUploadTypeMismatch)
+	}
+
+	// The read position of a seeker is reset.
+	data, _ := io.ReadAll(r)
+	if string(data) != html {
+		t.Errorf("reader was not rewound, got %d bytes", len(data))
+	}
+}
+
+func TestUploadPolicyValidate_AllowedTypes(t *testing.T) {
+	js := "function hello() { return 1; }\n"
+
+	v, err := utils.UploadPolicy{AllowedTypes: []string{"text/javascript"}}.Validate(strings.NewReader(js), "app.js", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !v.Allowed() {
+		t.Errorf("alias type must be allowed: %+v", v)
+	}
+
+	v, _ = utils.UploadPolicy{AllowedTypes: []string{"text/csv"}}.Validate(strings.NewReader(js), "notes.txt", "")
+	if !v.Has(utils.UploadIssueTypeNotAllowed) {
+		t.Errorf("expected type_not_allowed issue: %+v", v)
+	}
+	if err := v.Err(); !errors.Is(err, utils.ErrUploadTypeNotAllowed) {
+		t.Errorf("got error %v, want %v", err, utils.ErrUploadTypeNotAllowed)
+	}
+
+	if _, err := (utils.UploadPolicy{}).Validate(nil, "a.txt", ""); !errors.Is(err, utils.ErrInvalidReader) {
+		t.Errorf("got error %v, want %v", err, utils.ErrInvalidReader)
+	}
+}
+
+func TestUploadPolicyValidateFileHeader(t *testing.T) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, _ := w.CreateFormFile("file", "avatar.png")
+	_, _ = part.Write([]byte("<html><script>alert(1)</script></html>"))
+	_ = w.Close()
+
+	req := httptest.NewRequest("POST", "/upload", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	if err := req.ParseMultipartForm(1 << 20); err != nil {
+		t.Fatalf("failed to parse form: %v", err)
+	}
+
+	v, err := utils.UploadPolicy{AllowedTypes: []string{"image/*"}}.ValidateFileHeader(req.MultipartForm.File["file"][0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v.Allowed() || !v.Has(utils.UploadIssueExtensionMismatch) || !v.Has(utils.UploadIssueTypeNotAllowed) {
+		t.Errorf("unexpected verdict: %+v", v)
+	}
+}